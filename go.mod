@@ -1,3 +1,9 @@
 module github.com/arteev/go-pattern-tutorial
 
 go 1.13
+
+require (
+	github.com/antonmedv/expr v1.14.3
+	github.com/go-playground/validator/v10 v10.9.0
+	golang.org/x/text v0.3.6
+)
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// AccessHooks run around the handler granted by checkAccess, either may be nil
+type AccessHooks struct {
+	Before func(*User)
+	// After runs even if the handler panics, err is the recovered panic
+	After func(user *User, err error)
+}
+
+// runHandler runs the handler between the hooks. A panic of the handler is recovered
+// and returned as the error, with or without hooks.
+func runHandler(user *User, handler func(), hooks []AccessHooks) (err error) {
+	for _, h := range hooks {
+		if h.Before != nil {
+			h.Before(user)
+		}
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("handler panic: %v", r)
+		}
+		for _, h := range hooks {
+			if h.After != nil {
+				h.After(user, err)
+			}
+		}
+	}()
+	handler()
+	return nil
+}
+
+// checkAccessOnce works like checkAccess but runs the handler at most once per key,
+// the repeated calls are still granted
+func checkAccessOnce(spec SpecificationUser, name string, handler func(), keyFn func(*User) string) func(*User) error {
+	var mu sync.Mutex
+	seen := make(map[string]struct{})
+	return func(user *User) error {
+		key := keyFn(user)
+		return checkAccess(spec, name, func() {
+			mu.Lock()
+			_, done := seen[key]
+			seen[key] = struct{}{}
+			mu.Unlock()
+			if !done {
+				handler()
+			}
+		})(user)
+	}
+}
+
+// checkAccessContext works like checkAccess but evaluates the specification with
+// EvaluateContext, so the checks sharing a ScopedMemo context reuse the leaf results.
+// The reasons of a denial come from the evaluated tree through the same memo, a check
+// without a ScopedMemo gets one of its own, so no leaf is evaluated twice.
+func checkAccessContext(spec SpecificationUser, name string, handler func(), hooks ...AccessHooks) func(context.Context, *User) error {
+	return func(ctx context.Context, user *User) error {
+		if _, ok := ctx.Value(scopedMemoKey{}).(*scopedMemo); !ok {
+			ctx = ScopedMemo(ctx)
+		}
+		ok, err := EvaluateContext(ctx, spec, user)
+		if err != nil {
+			return fmt.Errorf("%s: access check failed, user: %v: %w", name, user, err)
+		}
+		if !ok {
+			if _, ok := spec.(Explainer); !ok {
+				return fmt.Errorf("%s: access denied, user: %v", name, user)
+			}
+			r, err := evaluateResultContext(ctx, spec, user)
+			if err != nil {
+				return fmt.Errorf("%s: access check failed, user: %v: %w", name, user, err)
+			}
+			reasons := reasonMessages(resultReasons(r, user))
+			return fmt.Errorf("%s: access denied, user: %v: %s", name, user, strings.Join(reasons, "; "))
+		}
+		fmt.Printf("%s: access granted, user: %v\n", name, user)
+		return runHandler(user, handler, hooks)
+	}
+}
+
+// DenialResponse describes a denial as a JSON-serializable structure for API clients:
+// the rule, the user summary, the reasons of Explain with their codes (Code*, empty for an
+// Explainer outside of the package) and the deciding leaves of MinimalCause
+func DenialResponse(spec SpecificationUser, u *User) map[string]interface{} {
+	causes := MinimalCause(spec, u)
+	names := make([]string, len(causes))
+	for i, cause := range causes {
+		names[i] = specString(cause)
+	}
+	reasons := explainReasons(spec, u)
+	messages := make([]string, len(reasons))
+	codes := make([]string, len(reasons))
+	for i, r := range reasons {
+		messages[i] = r.message
+		codes[i] = r.code
+	}
+	return map[string]interface{}{
+		"error": "access denied",
+		"rule":  specString(spec),
+		"user": map[string]interface{}{
+			"name":   u.Name,
+			"type":   u.Type.String(),
+			"locked": u.Locked,
+		},
+		"reasons": messages,
+		"codes":   codes,
+		"causes":  names,
+	}
+}
@@ -0,0 +1,185 @@
+package main
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestCheckAccessOnce(t *testing.T) {
+	var runs int
+	check := checkAccessOnce(NotLocked, "once", func() { runs++ }, userName)
+	boo := &User{Name: "boo"}
+	for i := 0; i < 2; i++ {
+		if err := check(boo); err != nil {
+			t.Fatalf("call #%d: %v", i+1, err)
+		}
+	}
+	if runs != 1 {
+		t.Errorf("handler ran %d times for one key, want 1", runs)
+	}
+	if err := check(&User{Name: "foo"}); err != nil || runs != 2 {
+		t.Errorf("another key: error %v, %d runs, want nil and 2", err, runs)
+	}
+	if err := check(&User{Name: "bar", Locked: true}); err == nil || runs != 2 {
+		t.Errorf("denied user: error %v, %d runs, want a denial and 2", err, runs)
+	}
+}
+
+func TestCheckAccessOnceConcurrent(t *testing.T) {
+	var runs int32
+	check := checkAccessOnce(NotLocked, "once", func() { atomic.AddInt32(&runs, 1) }, userName)
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := check(&User{Name: "boo"}); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+	if runs != 1 {
+		t.Errorf("handler ran %d times for one key, want 1", runs)
+	}
+}
+
+func TestCheckAccessExplainsAnd(t *testing.T) {
+	handled := false
+	check := checkAccess(And(NotLocked, IsAdmin, Not(IsNameShort4)), "admin panel", func() { handled = true })
+	err := check(&User{Name: "boo", Locked: true})
+	if err == nil || handled {
+		t.Fatalf("locked personal user granted")
+	}
+	for _, want := range []string{"admin panel: access denied", "Not(Locked): not satisfied", "Type(ADMIN): not satisfied", "Not(NameShort(4)): not satisfied"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q does not mention %q", err, want)
+		}
+	}
+	// the satisfied clauses are not reported
+	err = check(&User{Name: "alexander", Locked: true})
+	if err == nil || strings.Contains(err.Error(), "NameShort") || strings.Count(err.Error(), "not satisfied") != 2 {
+		t.Errorf("error %v does not list exactly the two failing clauses", err)
+	}
+}
+
+func TestCheckAccessNotExplainable(t *testing.T) {
+	err := checkAccess(Or(IsAdmin, IsSuperAdmin), "admin panel", func() {})(&User{Name: "boo"})
+	if err == nil || strings.Contains(err.Error(), "not satisfied") {
+		t.Errorf("error %v of a specification without Explain lists reasons", err)
+	}
+}
+
+func TestCheckAccessHooks(t *testing.T) {
+	var events []string
+	hooks := AccessHooks{
+		Before: func(u *User) { events = append(events, "before "+u.Name) },
+		After: func(u *User, err error) {
+			if err != nil {
+				events = append(events, "after "+u.Name+": "+err.Error())
+				return
+			}
+			events = append(events, "after "+u.Name)
+		},
+	}
+	tests := []struct {
+		name    string
+		user    *User
+		handler func()
+		err     string
+		events  []string
+	}{
+		{"granted", &User{Name: "boo"}, func() { events = append(events, "handler") }, "",
+			[]string{"before boo", "handler", "after boo"}},
+		{"handler panic", &User{Name: "boo"}, func() { panic("boom") }, "handler panic: boom",
+			[]string{"before boo", "after boo: handler panic: boom"}},
+		{"denied", &User{Name: "boo", Locked: true}, func() { events = append(events, "handler") },
+			`access denied`, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			events = nil
+			err := checkAccess(NotLocked, "hooks", tt.handler, hooks)(tt.user)
+			if tt.err == "" && err != nil || tt.err != "" && (err == nil || !strings.Contains(err.Error(), tt.err)) {
+				t.Errorf("error = %v, want %q", err, tt.err)
+			}
+			if !reflect.DeepEqual(events, tt.events) {
+				t.Errorf("events = %q, want %q", events, tt.events)
+			}
+		})
+	}
+}
+
+func TestCheckAccessWithoutHooksRecovers(t *testing.T) {
+	err := checkAccess(NotLocked, "no hooks", func() { panic("boom") })(&User{})
+	if err == nil || err.Error() != "handler panic: boom" {
+		t.Errorf("error = %v, want the recovered panic of the handler", err)
+	}
+	err = checkAccessContext(NotLocked, "no hooks", func() { panic("boom") })(context.Background(), &User{})
+	if err == nil || err.Error() != "handler panic: boom" {
+		t.Errorf("checkAccessContext error = %v, want the recovered panic of the handler", err)
+	}
+}
+
+func TestCheckAccessOnlyAfterHook(t *testing.T) {
+	var got error
+	hooks := AccessHooks{After: func(u *User, err error) { got = err }}
+	if err := checkAccess(NotLocked, "after only", func() { panic("boom") }, hooks)(&User{}); err == nil {
+		t.Fatalf("panicking handler returned no error")
+	}
+	if got == nil || got.Error() != "handler panic: boom" {
+		t.Errorf("After got %v, want the recovered panic", got)
+	}
+}
+
+func TestDenialResponse(t *testing.T) {
+	spec := And(NotLocked, Not(AnyAdmin), Not(IsNameShort4))
+	got := DenialResponse(spec, &User{Type: Admin, Name: "boo", Locked: true})
+	want := map[string]interface{}{
+		"error": "access denied",
+		"rule":  "And(Not(Locked), Not(Or(Type(ADMIN), Type(SUPER ADMIN))), Not(NameShort(4)))",
+		"user": map[string]interface{}{
+			"name":   "boo",
+			"type":   "ADMIN",
+			"locked": true,
+		},
+		"reasons": []string{
+			"Not(Locked): not satisfied",
+			"Not(Or(Type(ADMIN), Type(SUPER ADMIN))): not satisfied",
+			"Not(NameShort(4)): not satisfied",
+		},
+		"codes":  []string{CodeNotSatisfied, CodeNotSatisfied, CodeNotSatisfied},
+		"causes": []string{"Locked"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("DenialResponse =\n%v\nwant\n%v", got, want)
+	}
+	// a granted user has empty lists rather than nulls in JSON
+	got = DenialResponse(spec, &User{Name: "alexander"})
+	if reasons := got["reasons"].([]string); reasons == nil || len(reasons) != 0 {
+		t.Errorf("reasons of a granted user = %#v, want empty", reasons)
+	}
+	if codes := got["codes"].([]string); codes == nil || len(codes) != 0 {
+		t.Errorf("codes of a granted user = %#v, want empty", codes)
+	}
+}
+
+func TestDenialResponseCodes(t *testing.T) {
+	spec := And(
+		SatisfiesPolicy(UsernamePolicy{MinLength: 4, NoLeadingDigit: true}),
+		NoneOf(IsAdmin),
+		Requires(map[string]func(*User) bool{"unlocked": func(u *User) bool { return !u.Locked }}),
+	)
+	got := DenialResponse(spec, &User{Type: Admin, Name: "1ab", Locked: true})
+	want := []string{CodeNameTooShort, CodeNameLeadingDigit, CodeForbidden, CodeFieldInvalid}
+	if codes := got["codes"].([]string); !reflect.DeepEqual(codes, want) {
+		t.Errorf("codes = %q, want %q", codes, want)
+	}
+	if reasons := got["reasons"].([]string); len(reasons) != len(want) {
+		t.Errorf("%d reasons for %d codes, want one reason per code", len(reasons), len(want))
+	}
+}
@@ -0,0 +1,42 @@
+package main
+
+import "math/big"
+
+// Bitset returns the set of the users satisfying the specification:
+// bit i is set if users[i] satisfies it
+func Bitset(users []*User, spec SpecificationUser) *big.Int {
+	set := new(big.Int)
+	for i, u := range users {
+		if spec.IsSatisfiedBy(u) {
+			set.SetBit(set, i, 1)
+		}
+	}
+	return set
+}
+
+// BitsetAnd returns the users present in both sets, as if the specifications were And-ed
+func BitsetAnd(a, b *big.Int) *big.Int {
+	return new(big.Int).And(a, b)
+}
+
+// BitsetOr returns the users present in either set, as if the specifications were Or-ed
+func BitsetOr(a, b *big.Int) *big.Int {
+	return new(big.Int).Or(a, b)
+}
+
+// BitsetNot returns the users of n users absent from the set, as if the specification were negated
+func BitsetNot(set *big.Int, n int) *big.Int {
+	all := new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), uint(n)), big.NewInt(1))
+	return new(big.Int).AndNot(all, set)
+}
+
+// BitsetUsers returns the users whose bits are set
+func BitsetUsers(users []*User, set *big.Int) []*User {
+	var result []*User
+	for i, u := range users {
+		if set.Bit(i) == 1 {
+			result = append(result, u)
+		}
+	}
+	return result
+}
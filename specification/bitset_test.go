@@ -0,0 +1,46 @@
+package main
+
+import (
+	"testing"
+)
+
+// bitsetUsers covers every type, locked or not, with short and long names
+func bitsetUsers() []*User {
+	var users []*User
+	for _, typ := range []UserType{Personal, Admin, SuperAdmin} {
+		for _, name := range []string{"boo", "alexander"} {
+			users = append(users, &User{Type: typ, Name: name}, &User{Type: typ, Name: name, Locked: true})
+		}
+	}
+	return users
+}
+
+func TestBitsetOperations(t *testing.T) {
+	users := bitsetUsers()
+	a, b := AnyAdmin, Not(IsNameShort4)
+	setA, setB := Bitset(users, a), Bitset(users, b)
+	tests := []struct {
+		name string
+		got  string
+		spec SpecificationUser
+	}{
+		{"And", BitsetAnd(setA, setB).Text(2), And(a, b)},
+		{"Or", BitsetOr(setA, setB).Text(2), Or(a, b)},
+		{"Not", BitsetNot(setA, len(users)).Text(2), Not(a)},
+	}
+	for _, tt := range tests {
+		if want := Bitset(users, tt.spec).Text(2); tt.got != want {
+			t.Errorf("Bitset%s = %s, want %s", tt.name, tt.got, want)
+		}
+	}
+	got := BitsetUsers(users, BitsetAnd(setA, setB))
+	want := Filter(users, And(a, b))
+	if len(got) != len(want) {
+		t.Fatalf("BitsetUsers returned %d users, want %d", len(got), len(want))
+	}
+	for i := range got {
+		if got[i] != want[i] {
+			t.Errorf("user %d = %+v, want %+v", i, *got[i], *want[i])
+		}
+	}
+}
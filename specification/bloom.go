@@ -0,0 +1,105 @@
+package main
+
+import (
+	"encoding/binary"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"strings"
+)
+
+// BloomFilter is a compact probabilistic set of strings ignoring case: a string that
+// was added is always found in any case (no false negatives), a string that was not
+// may be found with the false positive rate the filter was sized for
+type BloomFilter struct {
+	bits []uint64
+	m    uint64 // number of bits
+	k    uint64 // number of hash functions
+}
+
+// NewBloomFilter sizes the filter for n strings and the false positive rate p (e.g. 0.01):
+// m = -n*ln(p)/ln(2)^2 bits and k = m/n*ln(2) hash functions
+func NewBloomFilter(n int, p float64) *BloomFilter {
+	if n < 1 {
+		n = 1
+	}
+	if p <= 0 || p >= 1 {
+		p = 0.01
+	}
+	m := uint64(math.Ceil(-float64(n) * math.Log(p) / (math.Ln2 * math.Ln2)))
+	k := uint64(math.Max(1, math.Round(float64(m)/float64(n)*math.Ln2)))
+	return &BloomFilter{
+		bits: make([]uint64, (m+63)/64),
+		m:    m,
+		k:    k,
+	}
+}
+
+// BloomFilterFrom returns a filter of the strings with a 1% false positive rate
+func BloomFilterFrom(values []string) *BloomFilter {
+	f := NewBloomFilter(len(values), 0.01)
+	for _, v := range values {
+		f.Add(v)
+	}
+	return f
+}
+
+// Add adds the string
+func (f *BloomFilter) Add(v string) {
+	h1, h2 := bloomHashes(v)
+	for i := uint64(0); i < f.k; i++ {
+		bit := (h1 + i*h2) % f.m
+		f.bits[bit/64] |= 1 << (bit % 64)
+	}
+}
+
+// Contains reports whether the string is probably in the set
+func (f *BloomFilter) Contains(v string) bool {
+	h1, h2 := bloomHashes(v)
+	for i := uint64(0); i < f.k; i++ {
+		bit := (h1 + i*h2) % f.m
+		if f.bits[bit/64]&(1<<(bit%64)) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// bloomHashes returns the two hashes of the double hashing h1 + i*h2 of the
+// lower-cased string, so Add and Contains ignore case
+func bloomHashes(v string) (uint64, uint64) {
+	h := fnv.New64a()
+	h.Write([]byte(strings.ToLower(v)))
+	sum := h.Sum64()
+	return sum >> 32, sum&0xffffffff | 1
+}
+
+// NameInBloom: the name is probably in the filter, ignoring case
+type NameInBloomSpecification struct {
+	filter *BloomFilter
+}
+
+func NameInBloom(filter *BloomFilter) *NameInBloomSpecification {
+	return &NameInBloomSpecification{
+		filter: filter,
+	}
+}
+
+func (s *NameInBloomSpecification) IsSatisfiedBy(u *User) bool {
+	return s.filter.Contains(u.Name)
+}
+
+func (s *NameInBloomSpecification) String() string {
+	h := fnv.New64a()
+	var buf [8]byte
+	for _, word := range s.filter.bits {
+		binary.LittleEndian.PutUint64(buf[:], word)
+		h.Write(buf[:])
+	}
+	return fmt.Sprintf("NameInBloom(%d bits, %d hashes, %016x)", s.filter.m, s.filter.k, h.Sum64())
+}
+
+// hashKey is the String, which hashes the bits of the filter
+func (s *NameInBloomSpecification) hashKey() string {
+	return s.String()
+}
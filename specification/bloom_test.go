@@ -0,0 +1,55 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestNameInBloom(t *testing.T) {
+	s := NameInBloom(BloomFilterFrom([]string{"boo", "foo", "alexander"}))
+	RunSpecTests(t, s, []SpecCase{
+		{User: &User{Name: "boo"}, Expected: true},
+		{User: &User{Name: "foo"}, Expected: true},
+		{Name: "case-insensitive", User: &User{Name: "Alexander"}, Expected: true},
+		{User: &User{Name: "zzzzzz-not-a-member"}, Expected: false},
+	})
+}
+
+func TestNameInBloomMixedCase(t *testing.T) {
+	s := NameInBloom(BloomFilterFrom([]string{"Alexander", "ЁЖИК"}))
+	RunSpecTests(t, s, []SpecCase{
+		{Name: "same case", User: &User{Name: "Alexander"}, Expected: true},
+		{Name: "lower case", User: &User{Name: "alexander"}, Expected: true},
+		{Name: "upper case", User: &User{Name: "ALEXANDER"}, Expected: true},
+		{Name: "multibyte", User: &User{Name: "ёжик"}, Expected: true},
+		{User: &User{Name: "zzzzzz-not-a-member"}, Expected: false},
+	})
+	f := NewBloomFilter(1, 0.01)
+	f.Add("Boo")
+	if !f.Contains("bOO") {
+		t.Errorf("Contains(bOO) = false after Add(Boo), want true")
+	}
+}
+
+func TestBloomFilterFalsePositiveRate(t *testing.T) {
+	const n = 1000
+	f := NewBloomFilter(n, 0.01)
+	for i := 0; i < n; i++ {
+		f.Add(fmt.Sprintf("member%d", i))
+	}
+	for i := 0; i < n; i++ {
+		if v := fmt.Sprintf("member%d", i); !f.Contains(v) {
+			t.Fatalf("Contains(%s) = false for an added string", v)
+		}
+	}
+	positives := 0
+	for i := 0; i < 10*n; i++ {
+		if f.Contains(fmt.Sprintf("absent%d", i)) {
+			positives++
+		}
+	}
+	// the rate is sized for 1%, allow for the variance of the sample
+	if rate := float64(positives) / (10 * n); rate > 0.02 {
+		t.Errorf("false positive rate = %.3f, want about 0.01", rate)
+	}
+}
@@ -0,0 +1,247 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestPoolCachedReturnsInnerResult(t *testing.T) {
+	s := PoolCached(NameShort(4), userName)
+	tests := []struct {
+		name string
+		want bool
+	}{
+		{"boo", true},
+		{"alexander", false},
+		{"boo", true},
+		{"", true},
+		{"alexander", false},
+	}
+	for _, tt := range tests {
+		if got := s.IsSatisfiedBy(&User{Name: tt.name}); got != tt.want {
+			t.Errorf("IsSatisfiedBy(%q) = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestPoolCachedConcurrent(t *testing.T) {
+	s := PoolCached(NameShort(4), userName)
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 1000; i++ {
+				u := &User{Name: fmt.Sprint(i % 20000)}
+				if got, want := s.IsSatisfiedBy(u), len(u.Name) <= 4; got != want {
+					t.Errorf("IsSatisfiedBy(%q) = %v, want %v", u.Name, got, want)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// benchCachedUsers are the users of the cache benchmarks, cycled by the goroutines
+func benchCachedUsers() []*User {
+	users := make([]*User, 256)
+	for i := range users {
+		users[i] = &User{Name: fmt.Sprintf("user%d", i)}
+	}
+	return users
+}
+
+func BenchmarkCachedParallel(b *testing.B) {
+	users := benchCachedUsers()
+	b.Run("Cached", func(b *testing.B) {
+		s := Cached(Contextual(ValidNameNotAdmin), nil, 0, userName)
+		ctx := context.Background()
+		b.RunParallel(func(pb *testing.PB) {
+			for i := 0; pb.Next(); i++ {
+				s.IsSatisfiedByContext(ctx, users[i%len(users)])
+			}
+		})
+	})
+	b.Run("PoolCached", func(b *testing.B) {
+		s := PoolCached(ValidNameNotAdmin, userName)
+		b.RunParallel(func(pb *testing.PB) {
+			for i := 0; pb.Next(); i++ {
+				s.IsSatisfiedBy(users[i%len(users)])
+			}
+		})
+	})
+}
+
+func TestLRUCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	c := NewLRUCache(2)
+	c.Set("a", true, 0)
+	c.Set("b", false, 0)
+	// a becomes the most recently used, so b is evicted by c
+	if val, ok := c.Get("a"); !val || !ok {
+		t.Fatalf("Get(a) = %v, %v, want true, true", val, ok)
+	}
+	c.Set("c", true, 0)
+	if c.Len() != 2 {
+		t.Errorf("Len = %d, want the cap 2", c.Len())
+	}
+	tests := []struct {
+		key     string
+		val, ok bool
+	}{
+		{"a", true, true},
+		{"b", false, false},
+		{"c", true, true},
+	}
+	for _, tt := range tests {
+		if val, ok := c.Get(tt.key); val != tt.val || ok != tt.ok {
+			t.Errorf("Get(%s) = %v, %v, want %v, %v", tt.key, val, ok, tt.val, tt.ok)
+		}
+	}
+	// updating an entry refreshes it without growing the cache
+	c.Set("a", false, 0)
+	c.Set("d", true, 0)
+	if _, ok := c.Get("c"); ok {
+		t.Errorf("c survived although a was updated after it")
+	}
+	if val, ok := c.Get("a"); val || !ok {
+		t.Errorf("Get(a) = %v, %v, want false, true", val, ok)
+	}
+}
+
+func TestLRUCacheExpiration(t *testing.T) {
+	clock := &fakeClock{t: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)}
+	c := NewLRUCache(10).WithClock(clock.now)
+	c.Set("short", true, time.Minute)
+	c.Set("forever", true, 0)
+	clock.t = clock.t.Add(time.Minute)
+	if _, ok := c.Get("short"); ok {
+		t.Errorf("entry found at its expiration")
+	}
+	if _, ok := c.Get("forever"); !ok {
+		t.Errorf("entry without ttl expired")
+	}
+	if c.Len() != 1 {
+		t.Errorf("Len = %d, want the expired entry evicted", c.Len())
+	}
+}
+
+func TestCachedReadThrough(t *testing.T) {
+	leaf := &countingSpec{ok: true}
+	s := Cached(Contextual(leaf), NewLRUCache(1), 0, userName)
+	ctx := context.Background()
+	boo, foo := &User{Name: "boo"}, &User{Name: "foo"}
+	for _, u := range []*User{boo, boo, foo, boo} {
+		if ok, err := s.IsSatisfiedByContext(ctx, u); !ok || err != nil {
+			t.Fatalf("IsSatisfiedByContext(%s) = %v, %v", u.Name, ok, err)
+		}
+	}
+	// boo is cached, foo evicts it from the cache of one entry, boo is evaluated again
+	if leaf.calls != 3 {
+		t.Errorf("inner evaluated %d times, want 3", leaf.calls)
+	}
+}
+
+func TestVersioned(t *testing.T) {
+	leaf := &countingSpec{ok: true}
+	version := map[*User]int64{}
+	s := Versioned(leaf, func(u *User) int64 { return version[u] })
+	boo, foo := &User{Name: "boo"}, &User{Name: "foo"}
+	steps := []struct {
+		name  string
+		user  *User
+		bump  bool
+		calls int
+	}{
+		{"first evaluation", boo, false, 1},
+		{"stable version reuses the result", boo, false, 1},
+		{"another user", foo, false, 2},
+		{"bumped version recomputes", boo, true, 3},
+		{"new version is cached", boo, false, 3},
+	}
+	for _, step := range steps {
+		if step.bump {
+			version[step.user]++
+		}
+		s.IsSatisfiedBy(step.user)
+		if leaf.calls != step.calls {
+			t.Errorf("%s: inner evaluated %d times, want %d", step.name, leaf.calls, step.calls)
+		}
+	}
+	// the recomputed result replaces the cached one
+	leaf.ok = false
+	version[boo]++
+	if s.IsSatisfiedBy(boo) {
+		t.Errorf("IsSatisfiedBy returned the result of the previous version")
+	}
+}
+
+func TestCachedSplitTTL(t *testing.T) {
+	clock := &fakeClock{t: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)}
+	leaf := &countingSpec{}
+	s := CachedSplitTTL(Contextual(leaf), time.Hour, time.Minute, userName).
+		WithCache(NewLRUCache(16).WithClock(clock.now))
+	ctx := context.Background()
+	denied, granted := &User{Name: "denied"}, &User{Name: "granted"}
+
+	// evaluate denied while the leaf fails and granted while it passes
+	s.IsSatisfiedByContext(ctx, denied)
+	leaf.ok = true
+	s.IsSatisfiedByContext(ctx, granted)
+	leaf.ok = false
+
+	steps := []struct {
+		name    string
+		advance time.Duration
+		user    *User
+		want    bool
+		calls   int
+	}{
+		{"cached false", 59 * time.Second, denied, false, 2},
+		{"false expires at negTTL", time.Second, denied, false, 3},
+		{"cached true outlives negTTL", 58 * time.Minute, granted, true, 3},
+		{"true expires at posTTL", time.Minute, granted, false, 4},
+	}
+	for _, step := range steps {
+		clock.t = clock.t.Add(step.advance)
+		if got, err := s.IsSatisfiedByContext(ctx, step.user); got != step.want || err != nil {
+			t.Errorf("%s: IsSatisfiedByContext = %v, %v, want %v", step.name, got, err, step.want)
+		}
+		if leaf.calls != step.calls {
+			t.Errorf("%s: inner evaluated %d times, want %d", step.name, leaf.calls, step.calls)
+		}
+	}
+}
+
+func TestCachedStats(t *testing.T) {
+	s := Cached(Contextual(NotLocked), nil, 0, userName)
+	ctx := context.Background()
+	boo := &User{Name: "boo"}
+	s.IsSatisfiedByContext(ctx, boo)
+	s.IsSatisfiedByContext(ctx, boo)
+	if hits, misses := s.Stats(); hits != 1 || misses != 1 {
+		t.Errorf("Stats after a miss and a hit = %d, %d, want 1, 1", hits, misses)
+	}
+	s.ResetStats()
+	if hits, misses := s.Stats(); hits != 0 || misses != 0 {
+		t.Errorf("Stats after ResetStats = %d, %d, want 0, 0", hits, misses)
+	}
+	// the entries survive the reset
+	s.IsSatisfiedByContext(ctx, boo)
+	if hits, misses := s.Stats(); hits != 1 || misses != 0 {
+		t.Errorf("Stats after the reset = %d, %d, want 1, 0", hits, misses)
+	}
+
+	// a failed evaluation is a miss and is not cached
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	failing := Cached(&instrumentedSpec{ok: true, delay: time.Hour}, nil, 0, userName)
+	failing.IsSatisfiedByContext(canceled, boo)
+	failing.IsSatisfiedByContext(canceled, boo)
+	if hits, misses := failing.Stats(); hits != 0 || misses != 2 {
+		t.Errorf("Stats of failed evaluations = %d, %d, want 0, 2", hits, misses)
+	}
+}
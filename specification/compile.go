@@ -0,0 +1,139 @@
+package main
+
+import "strings"
+
+// Compile walks the tree once and returns an equivalent predicate.
+// Negations are folded into the leaves, the built-in leaves read the user fields
+// directly and the subtrees that depend only on the user type are precomputed into
+// a lookup table, so the evaluation does not dispatch through the SpecificationUser
+// interface on every node. Other leaves are called through IsSatisfiedBy.
+//
+// BenchmarkCompile runs ValidNameNotAdmin over a million random users, compiled it is
+// about 10% faster than the tree: the unpredictable branches dominate both.
+func Compile(spec SpecificationUser) func(*User) bool {
+	return compile(spec, false)
+}
+
+func compile(spec SpecificationUser, neg bool) func(*User) bool {
+	if table, ok := typeTable(spec); ok {
+		return func(u *User) bool {
+			if u.Type >= 0 && int(u.Type) < len(table)-1 {
+				return table[u.Type] != neg
+			}
+			// types outside of the known range share the last entry
+			return table[len(table)-1] != neg
+		}
+	}
+	switch s := spec.(type) {
+	case *NotSpecification:
+		return compile(s.spec, !neg)
+	case *AndSpecification:
+		// NOT (a AND b) = NOT a OR NOT b
+		return compileJoin(s.specs, neg, neg)
+	case *OrSpecification:
+		return compileJoin(s.specs, neg, !neg)
+	case *NameLengthSpecification:
+		l := s.l
+		return func(u *User) bool {
+			return (len(u.Name) <= l) != neg
+		}
+	case *NameSpecification:
+		name := s.name
+		return func(u *User) bool {
+			return (strings.ToLower(u.Name) == name) != neg
+		}
+	case *LockedSpecification:
+		return func(u *User) bool {
+			return u.Locked != neg
+		}
+	}
+	if neg {
+		return func(u *User) bool {
+			return !spec.IsSatisfiedBy(u)
+		}
+	}
+	return spec.IsSatisfiedBy
+}
+
+// compileJoin returns any when one of the children returns any, otherwise !any
+func compileJoin(specs []SpecificationUser, neg, any bool) func(*User) bool {
+	fns := make([]func(*User) bool, len(specs))
+	for i, spec := range specs {
+		fns[i] = compile(spec, neg)
+	}
+	switch len(fns) {
+	case 1:
+		return fns[0]
+	case 2:
+		a, b := fns[0], fns[1]
+		if any {
+			return func(u *User) bool { return a(u) || b(u) }
+		}
+		return func(u *User) bool { return a(u) && b(u) }
+	case 3:
+		a, b, c := fns[0], fns[1], fns[2]
+		if any {
+			return func(u *User) bool { return a(u) || b(u) || c(u) }
+		}
+		return func(u *User) bool { return a(u) && b(u) && c(u) }
+	}
+	return func(u *User) bool {
+		for _, fn := range fns {
+			if fn(u) == any {
+				return any
+			}
+		}
+		return !any
+	}
+}
+
+// typeTable precomputes the result of a subtree that reads only the user type
+// for each known type, plus the last entry for any other type
+func typeTable(spec SpecificationUser) ([]bool, bool) {
+	if !onlyType(spec) {
+		return nil, false
+	}
+	table := make([]bool, len(userTypeNames)+1)
+	for i := range table {
+		typ := UserType(i)
+		if i == len(table)-1 {
+			typ = -1
+		}
+		table[i] = spec.IsSatisfiedBy(&User{Type: typ})
+	}
+	return table, true
+}
+
+func onlyType(spec SpecificationUser) bool {
+	switch s := spec.(type) {
+	case *TypeSpecification:
+		return int(s.typ) >= 0 && int(s.typ) < len(userTypeNames)
+	case *TypeInSpecification:
+		for typ := range s.types {
+			if int(typ) < 0 || int(typ) >= len(userTypeNames) {
+				return false
+			}
+		}
+		return true
+	case *NotSpecification:
+		return onlyType(s.spec)
+	case *AndSpecification:
+		return allOnlyType(s.specs)
+	case *OrSpecification:
+		return allOnlyType(s.specs)
+	case combiner:
+		// the built-in composites without side effects, unlike Pipeline whose
+		// actions must run on every evaluation
+		return allOnlyType(s.Children())
+	}
+	return false
+}
+
+func allOnlyType(specs []SpecificationUser) bool {
+	for _, spec := range specs {
+		if !onlyType(spec) {
+			return false
+		}
+	}
+	return true
+}
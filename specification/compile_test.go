@@ -0,0 +1,108 @@
+package main
+
+import (
+	"math/rand"
+	"testing"
+)
+
+// randomUser returns a user with few names and types, so the leaves pass and fail often
+func randomUser(r *rand.Rand) *User {
+	names := []string{"", "boo", "Foo", "alexander", "FOO"}
+	return &User{
+		Type:   UserType(r.Intn(len(userTypeNames)+1) - 1),
+		Name:   names[r.Intn(len(names))],
+		Locked: r.Intn(2) == 0,
+	}
+}
+
+// randomSpec returns a tree of the given depth over the leaves compiled directly,
+// the type-only subtrees, and the leaves and composites called through IsSatisfiedBy
+func randomSpec(r *rand.Rand, depth int) SpecificationUser {
+	if depth == 0 || r.Intn(4) == 0 {
+		leaves := []SpecificationUser{
+			IsAdmin, IsSuperAdmin, IsPersonal, TypeIn(Personal, SuperAdmin), TypeIn(42),
+			Locked, NameShort(3), Name("foo"), SatisfiesPolicy(UsernamePolicy{MinLength: 4}),
+		}
+		return leaves[r.Intn(len(leaves))]
+	}
+	children := make([]SpecificationUser, 1+r.Intn(4))
+	for i := range children {
+		children[i] = randomSpec(r, depth-1)
+	}
+	switch r.Intn(6) {
+	case 0:
+		return Not(children[0])
+	case 1:
+		return And(children...)
+	case 2:
+		return Or(children...)
+	case 3:
+		return NoneOf(children...)
+	case 4:
+		return Majority(children...)
+	}
+	return SatisfiedInRange(1, 2, children...)
+}
+
+func TestCompileAgreesWithTree(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 500; i++ {
+		spec := randomSpec(r, 4)
+		fn := Compile(spec)
+		for j := 0; j < 50; j++ {
+			u := randomUser(r)
+			if got, want := fn(u), spec.IsSatisfiedBy(u); got != want {
+				t.Fatalf("Compile(%s)(%+v) = %v, tree = %v", specString(spec), *u, got, want)
+			}
+		}
+	}
+}
+
+func TestCompileTypeTable(t *testing.T) {
+	fn := Compile(Not(Or(IsAdmin, TypeIn(SuperAdmin))))
+	tests := []struct {
+		typ  UserType
+		want bool
+	}{
+		{Personal, true},
+		{Admin, false},
+		{SuperAdmin, false},
+		{-1, true},
+		{42, true},
+	}
+	for _, tt := range tests {
+		if got := fn(&User{Type: tt.typ}); got != tt.want {
+			t.Errorf("compiled(%s) = %v, want %v", tt.typ, got, tt.want)
+		}
+	}
+}
+
+// compileBenchUsers are the million users of the Compile benchmarks
+func compileBenchUsers() []User {
+	r := rand.New(rand.NewSource(1))
+	users := make([]User, 1000000)
+	for i := range users {
+		users[i] = *randomUser(r)
+	}
+	return users
+}
+
+func BenchmarkCompile(b *testing.B) {
+	users := compileBenchUsers()
+	b.Run("tree", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			for j := range users {
+				ValidNameNotAdmin.IsSatisfiedBy(&users[j])
+			}
+		}
+	})
+	b.Run("compiled", func(b *testing.B) {
+		fn := Compile(ValidNameNotAdmin)
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			for j := range users {
+				fn(&users[j])
+			}
+		}
+	})
+}
@@ -0,0 +1,358 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// NoneOf: none of the forbidden conditions holds.
+// It is the same as Not(Or(...)), but Explain names the triggered conditions.
+type NoneOfSpecification struct {
+	specs []SpecificationUser
+}
+
+func NoneOf(specs ...SpecificationUser) *NoneOfSpecification {
+	return &NoneOfSpecification{
+		specs: specs,
+	}
+}
+
+func (s *NoneOfSpecification) IsSatisfiedBy(u *User) bool {
+	for _, spec := range s.specs {
+		if spec.IsSatisfiedBy(u) {
+			return false
+		}
+	}
+	return true
+}
+
+func (s *NoneOfSpecification) Children() []SpecificationUser {
+	return s.specs
+}
+
+func (s *NoneOfSpecification) combine(results []bool) bool {
+	for _, ok := range results {
+		if ok {
+			return false
+		}
+	}
+	return true
+}
+
+// Explain reports every triggered forbidden condition in order
+func (s *NoneOfSpecification) Explain(u *User) []string {
+	return reasonMessages(s.reasons(u))
+}
+
+func (s *NoneOfSpecification) reasons(u *User) []reason {
+	var reasons []reason
+	for _, spec := range s.specs {
+		if spec.IsSatisfiedBy(u) {
+			reasons = append(reasons, newReason(CodeForbidden, specString(spec)))
+		}
+	}
+	return reasons
+}
+
+func (s *NoneOfSpecification) String() string {
+	return "NoneOf(" + joinSpecStrings(s.specs) + ")"
+}
+
+// SatisfiedInRange: the number of satisfied children is within [lo, hi].
+// An empty range (lo > hi) is never satisfied.
+type RangeSpecification struct {
+	lo, hi int
+	specs  []SpecificationUser
+}
+
+func SatisfiedInRange(lo, hi int, specs ...SpecificationUser) *RangeSpecification {
+	return &RangeSpecification{
+		lo:    lo,
+		hi:    hi,
+		specs: specs,
+	}
+}
+
+// IsSatisfiedBy stops as soon as the count is known to land in or out of the range
+func (s *RangeSpecification) IsSatisfiedBy(u *User) bool {
+	if s.lo > s.hi {
+		return false
+	}
+	count := 0
+	for i, spec := range s.specs {
+		remaining := len(s.specs) - i
+		if count > s.hi || count+remaining < s.lo {
+			return false
+		}
+		if count >= s.lo && count+remaining <= s.hi {
+			return true
+		}
+		if spec.IsSatisfiedBy(u) {
+			count++
+		}
+	}
+	return count >= s.lo && count <= s.hi
+}
+
+func (s *RangeSpecification) Children() []SpecificationUser {
+	return s.specs
+}
+
+func (s *RangeSpecification) combine(results []bool) bool {
+	count := 0
+	for _, ok := range results {
+		if ok {
+			count++
+		}
+	}
+	return s.lo <= s.hi && count >= s.lo && count <= s.hi
+}
+
+func (s *RangeSpecification) String() string {
+	return fmt.Sprintf("SatisfiedInRange(%d, %d, %s)", s.lo, s.hi, joinSpecStrings(s.specs))
+}
+
+// ConfidenceSpec is a specification with the confidence of its match
+type ConfidenceSpec struct {
+	Spec       SpecificationUser
+	Confidence float64
+}
+
+// OrWeighted: the confidences of the satisfied specifications sum up to at least min,
+// so several weak matches can pass where a single one cannot
+type OrWeightedSpecification struct {
+	min   float64
+	pairs []ConfidenceSpec
+}
+
+func OrWeighted(min float64, pairs ...ConfidenceSpec) *OrWeightedSpecification {
+	return &OrWeightedSpecification{
+		min:   min,
+		pairs: pairs,
+	}
+}
+
+func (s *OrWeightedSpecification) IsSatisfiedBy(u *User) bool {
+	total := 0.0
+	for _, p := range s.pairs {
+		if total >= s.min {
+			return true
+		}
+		if p.Spec.IsSatisfiedBy(u) {
+			total += p.Confidence
+		}
+	}
+	return total >= s.min
+}
+
+func (s *OrWeightedSpecification) Children() []SpecificationUser {
+	specs := make([]SpecificationUser, len(s.pairs))
+	for i, p := range s.pairs {
+		specs[i] = p.Spec
+	}
+	return specs
+}
+
+func (s *OrWeightedSpecification) combine(results []bool) bool {
+	total := 0.0
+	for i, ok := range results {
+		if ok {
+			total += s.pairs[i].Confidence
+		}
+	}
+	return total >= s.min
+}
+
+func (s *OrWeightedSpecification) String() string {
+	parts := make([]string, len(s.pairs))
+	for i, p := range s.pairs {
+		parts[i] = fmt.Sprintf("%s: %g", specString(p.Spec), p.Confidence)
+	}
+	return fmt.Sprintf("OrWeighted(%g, %s)", s.min, strings.Join(parts, ", "))
+}
+
+// Agree: all children return the same result, all satisfied or all not.
+// With no or a single child it is vacuously satisfied.
+type AgreeSpecification struct {
+	specs []SpecificationUser
+}
+
+func Agree(specs ...SpecificationUser) *AgreeSpecification {
+	return &AgreeSpecification{
+		specs: specs,
+	}
+}
+
+func (s *AgreeSpecification) IsSatisfiedBy(u *User) bool {
+	if len(s.specs) < 2 {
+		return true
+	}
+	first := s.specs[0].IsSatisfiedBy(u)
+	for _, spec := range s.specs[1:] {
+		if spec.IsSatisfiedBy(u) != first {
+			return false
+		}
+	}
+	return true
+}
+
+func (s *AgreeSpecification) Children() []SpecificationUser {
+	return s.specs
+}
+
+func (s *AgreeSpecification) combine(results []bool) bool {
+	for _, ok := range results {
+		if ok != results[0] {
+			return false
+		}
+	}
+	return true
+}
+
+func (s *AgreeSpecification) String() string {
+	return "Agree(" + joinSpecStrings(s.specs) + ")"
+}
+
+// Majority: more than half of the children are satisfied, so exactly half of
+// an even number fails and no children fail. The evaluation stops as soon as
+// the outcome is decided.
+type MajoritySpecification struct {
+	specs []SpecificationUser
+}
+
+func Majority(specs ...SpecificationUser) *MajoritySpecification {
+	return &MajoritySpecification{
+		specs: specs,
+	}
+}
+
+func (s *MajoritySpecification) IsSatisfiedBy(u *User) bool {
+	need := len(s.specs)/2 + 1
+	passed := 0
+	for i, spec := range s.specs {
+		if spec.IsSatisfiedBy(u) {
+			passed++
+			if passed >= need {
+				return true
+			}
+		}
+		if passed+len(s.specs)-i-1 < need {
+			return false
+		}
+	}
+	return false
+}
+
+func (s *MajoritySpecification) Children() []SpecificationUser {
+	return s.specs
+}
+
+func (s *MajoritySpecification) combine(results []bool) bool {
+	passed := 0
+	for _, ok := range results {
+		if ok {
+			passed++
+		}
+	}
+	return passed > len(results)/2
+}
+
+func (s *MajoritySpecification) String() string {
+	return "Majority(" + joinSpecStrings(s.specs) + ")"
+}
+
+// WeightedSpec is a specification with the weight it contributes when satisfied
+type WeightedSpec struct {
+	Spec   SpecificationUser
+	Weight float64
+}
+
+// Policy: no veto matches and the weights of the satisfied specifications sum up to
+// at least the threshold, i.e. any red flag blocks, otherwise the green flags accumulate
+type PolicySpecification struct {
+	threshold float64
+	weighted  []WeightedSpec
+	vetoes    []SpecificationUser
+}
+
+func Policy(threshold float64, weighted []WeightedSpec, vetoes []SpecificationUser) *PolicySpecification {
+	return &PolicySpecification{
+		threshold: threshold,
+		weighted:  weighted,
+		vetoes:    vetoes,
+	}
+}
+
+func (s *PolicySpecification) IsSatisfiedBy(u *User) bool {
+	for _, veto := range s.vetoes {
+		if veto.IsSatisfiedBy(u) {
+			return false
+		}
+	}
+	total := 0.0
+	for _, w := range s.weighted {
+		if w.Spec.IsSatisfiedBy(u) {
+			total += w.Weight
+		}
+	}
+	return total >= s.threshold
+}
+
+func (s *PolicySpecification) Children() []SpecificationUser {
+	specs := make([]SpecificationUser, 0, len(s.vetoes)+len(s.weighted))
+	specs = append(specs, s.vetoes...)
+	for _, w := range s.weighted {
+		specs = append(specs, w.Spec)
+	}
+	return specs
+}
+
+// combine takes the results in the order of Children: the vetoes, then the weighted
+func (s *PolicySpecification) combine(results []bool) bool {
+	for _, vetoed := range results[:len(s.vetoes)] {
+		if vetoed {
+			return false
+		}
+	}
+	total := 0.0
+	for i, ok := range results[len(s.vetoes):] {
+		if ok {
+			total += s.weighted[i].Weight
+		}
+	}
+	return total >= s.threshold
+}
+
+func (s *PolicySpecification) String() string {
+	parts := make([]string, len(s.weighted))
+	for i, w := range s.weighted {
+		parts[i] = fmt.Sprintf("%s: %g", specString(w.Spec), w.Weight)
+	}
+	return fmt.Sprintf("Policy(%g, [%s], vetoes [%s])", s.threshold, strings.Join(parts, ", "), joinSpecStrings(s.vetoes))
+}
+
+// NotIf is the specification when the condition is false and its negation when it is
+// true, the condition is fixed at construction, e.g. from a feature flag
+type NotIfSpecification struct {
+	negate bool
+	spec   SpecificationUser
+}
+
+func NotIf(condition bool, spec SpecificationUser) *NotIfSpecification {
+	return &NotIfSpecification{
+		negate: condition,
+		spec:   spec,
+	}
+}
+
+func (s *NotIfSpecification) IsSatisfiedBy(u *User) bool {
+	return s.spec.IsSatisfiedBy(u) != s.negate
+}
+
+func (s *NotIfSpecification) Inner() SpecificationUser {
+	return s.spec
+}
+
+func (s *NotIfSpecification) String() string {
+	return fmt.Sprintf("NotIf(%t, %s)", s.negate, specString(s.spec))
+}
@@ -0,0 +1,254 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNoneOfExplain(t *testing.T) {
+	s := NoneOf(IsAdmin, Locked, IsNameShort4)
+	tests := []struct {
+		name    string
+		user    *User
+		reasons []string
+	}{
+		{"none triggered", &User{Name: "alexander"}, nil},
+		{"first triggered", &User{Type: Admin, Name: "alexander"}, []string{"forbidden condition Type(ADMIN)"}},
+		{"all triggered", &User{Type: Admin, Name: "boo", Locked: true}, []string{
+			"forbidden condition Type(ADMIN)", "forbidden condition Locked", "forbidden condition NameShort(4)",
+		}},
+		{"last triggered", &User{Name: "boo"}, []string{"forbidden condition NameShort(4)"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Explain(s, tt.user); !reflect.DeepEqual(got, tt.reasons) {
+				t.Errorf("Explain = %q, want %q", got, tt.reasons)
+			}
+			if got, want := s.IsSatisfiedBy(tt.user), tt.reasons == nil; got != want {
+				t.Errorf("IsSatisfiedBy = %v, want %v", got, want)
+			}
+			if got, want := Evaluate(s, tt.user).Ok, tt.reasons == nil; got != want {
+				t.Errorf("Evaluate = %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+// constSpecs returns n specifications of which the first k are satisfied
+func constSpecs(n, k int) []SpecificationUser {
+	specs := make([]SpecificationUser, n)
+	for i := range specs {
+		ok := i < k
+		specs[i] = SpecFunc(func(*User) bool { return ok })
+	}
+	return specs
+}
+
+func TestSatisfiedInRangeBoundaries(t *testing.T) {
+	ranges := []struct{ lo, hi int }{
+		{0, 0}, {0, 4}, {1, 1}, {2, 3}, {3, 4}, {4, 4}, {5, 6}, {-1, 0},
+		// impossible ranges
+		{3, 2}, {1, 0},
+	}
+	u := &User{}
+	for _, r := range ranges {
+		for k := 0; k <= 4; k++ {
+			s := SatisfiedInRange(r.lo, r.hi, constSpecs(4, k)...)
+			want := r.lo <= r.hi && k >= r.lo && k <= r.hi
+			if got := s.IsSatisfiedBy(u); got != want {
+				t.Errorf("SatisfiedInRange(%d, %d) with %d of 4 satisfied = %v, want %v", r.lo, r.hi, k, got, want)
+			}
+			if got := Evaluate(s, u).Ok; got != want {
+				t.Errorf("Evaluate(SatisfiedInRange(%d, %d)) with %d of 4 satisfied = %v, want %v", r.lo, r.hi, k, got, want)
+			}
+		}
+	}
+}
+
+func TestSatisfiedInRangeShortCircuit(t *testing.T) {
+	tests := []struct {
+		name      string
+		lo, hi    int
+		oks       []bool
+		evaluated int
+	}{
+		{"hi exceeded", 0, 1, []bool{true, true, true, true}, 2},
+		{"lo unreachable", 3, 4, []bool{false, false, true, true}, 2},
+		{"in range whatever the rest", 1, 4, []bool{true, false, false, false}, 1},
+		{"impossible range", 2, 1, []bool{true, true}, 0},
+	}
+	for _, tt := range tests {
+		leaves := make([]*countingSpec, len(tt.oks))
+		specs := make([]SpecificationUser, len(tt.oks))
+		for i, ok := range tt.oks {
+			leaves[i] = &countingSpec{ok: ok}
+			specs[i] = leaves[i]
+		}
+		SatisfiedInRange(tt.lo, tt.hi, specs...).IsSatisfiedBy(&User{})
+		evaluated := 0
+		for _, leaf := range leaves {
+			evaluated += leaf.calls
+		}
+		if evaluated != tt.evaluated {
+			t.Errorf("%s: %d children evaluated, want %d", tt.name, evaluated, tt.evaluated)
+		}
+	}
+}
+
+func TestOrWeighted(t *testing.T) {
+	s := OrWeighted(0.7,
+		ConfidenceSpec{Name("boo"), 0.4},
+		ConfidenceSpec{Not(Locked), 0.4},
+		ConfidenceSpec{IsSuperAdmin, 0.9},
+	)
+	tests := []struct {
+		name string
+		user *User
+		want bool
+	}{
+		{"two low-confidence matches", &User{Name: "boo"}, true},
+		{"one low-confidence match", &User{Name: "foo"}, false},
+		{"the other one alone", &User{Name: "boo", Locked: true}, false},
+		{"one high-confidence match", &User{Type: SuperAdmin, Name: "foo", Locked: true}, true},
+		{"no match", &User{Name: "foo", Locked: true}, false},
+	}
+	for _, tt := range tests {
+		if got := s.IsSatisfiedBy(tt.user); got != tt.want {
+			t.Errorf("%s: IsSatisfiedBy = %v, want %v", tt.name, got, tt.want)
+		}
+		if got := Evaluate(s, tt.user).Ok; got != tt.want {
+			t.Errorf("%s: Evaluate = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+	if OrWeighted(0).IsSatisfiedBy(&User{}) != true || OrWeighted(0.1).IsSatisfiedBy(&User{}) != false {
+		t.Errorf("OrWeighted without children does not compare 0 with the threshold")
+	}
+}
+
+func TestAgree(t *testing.T) {
+	tests := []struct {
+		name  string
+		specs []SpecificationUser
+		want  bool
+	}{
+		{"mixed", constSpecs(3, 1), false},
+		{"mixed the other way", constSpecs(3, 2), false},
+		{"all true", constSpecs(3, 3), true},
+		{"all false", constSpecs(3, 0), true},
+		{"single true", constSpecs(1, 1), true},
+		{"single false", constSpecs(1, 0), true},
+		{"no children", nil, true},
+	}
+	u := &User{}
+	for _, tt := range tests {
+		s := Agree(tt.specs...)
+		if got := s.IsSatisfiedBy(u); got != tt.want {
+			t.Errorf("%s: IsSatisfiedBy = %v, want %v", tt.name, got, tt.want)
+		}
+		if got := Evaluate(s, u).Ok; got != tt.want {
+			t.Errorf("%s: Evaluate = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestPolicy(t *testing.T) {
+	s := Policy(1.0,
+		[]WeightedSpec{{Not(IsNameShort4), 0.5}, {NotLocked, 0.5}, {IsSuperAdmin, 2}},
+		[]SpecificationUser{Name("root")},
+	)
+	tests := []struct {
+		name string
+		user *User
+		want bool
+	}{
+		{"threshold met exactly", &User{Name: "alexander"}, true},
+		{"below threshold", &User{Name: "boo"}, false},
+		{"above threshold", &User{Type: SuperAdmin, Name: "alexander"}, true},
+		{"veto overrides a high score", &User{Type: SuperAdmin, Name: "root"}, false},
+		{"nothing satisfied", &User{Name: "boo", Locked: true}, false},
+	}
+	for _, tt := range tests {
+		if got := s.IsSatisfiedBy(tt.user); got != tt.want {
+			t.Errorf("%s: IsSatisfiedBy = %v, want %v", tt.name, got, tt.want)
+		}
+		if got := Evaluate(s, tt.user).Ok; got != tt.want {
+			t.Errorf("%s: Evaluate = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+	if !Policy(0, nil, nil).IsSatisfiedBy(&User{}) {
+		t.Errorf("Policy(0) without specifications is not satisfied")
+	}
+	want := "Policy(1, [Not(NameShort(4)): 0.5, Not(Locked): 0.5, Type(SUPER ADMIN): 2], vetoes [Name(root)])"
+	if got := s.String(); got != want {
+		t.Errorf("String = %s, want %s", got, want)
+	}
+}
+
+func TestNotIf(t *testing.T) {
+	tests := []struct {
+		condition bool
+		cases     []SpecCase
+	}{
+		{false, []SpecCase{
+			{User: &User{Type: Admin}, Expected: true},
+			{User: &User{Type: Personal}, Expected: false},
+		}},
+		{true, []SpecCase{
+			{User: &User{Type: Admin}, Expected: false},
+			{User: &User{Type: Personal}, Expected: true},
+		}},
+	}
+	for _, tt := range tests {
+		s := NotIf(tt.condition, IsAdmin)
+		RunSpecTests(t, s, tt.cases)
+		for _, c := range tt.cases {
+			if got := Evaluate(s, c.User).Ok; got != c.Expected {
+				t.Errorf("Evaluate(%s) of %s = %v, want %v", s, c.User.Type, got, c.Expected)
+			}
+		}
+	}
+	if got, want := NotIf(true, IsAdmin).String(), "NotIf(true, Type(ADMIN))"; got != want {
+		t.Errorf("String = %s, want %s", got, want)
+	}
+
+	sqlTests := []struct {
+		spec  SpecificationUser
+		where string
+	}{
+		{NotIf(false, IsAdmin), "type = ?"},
+		{NotIf(true, IsAdmin), "type <> ?"},
+		{Not(NotIf(true, IsAdmin)), "type = ?"},
+	}
+	for _, tt := range sqlTests {
+		if where, _, err := ToSQL(tt.spec); where != tt.where || err != nil {
+			t.Errorf("ToSQL(%s) = %q, %v, want %q", specString(tt.spec), where, err, tt.where)
+		}
+	}
+}
+
+func TestMajority(t *testing.T) {
+	tests := []struct {
+		name string
+		n, k int
+		want bool
+	}{
+		{"2 of 3", 3, 2, true},
+		{"1 of 3", 3, 1, false},
+		{"3 of 3", 3, 3, true},
+		{"2 of 4 is a tie", 4, 2, false},
+		{"3 of 4", 4, 3, true},
+		{"1 of 1", 1, 1, true},
+		{"0 of 1", 1, 0, false},
+		{"no children", 0, 0, false},
+	}
+	u := &User{}
+	for _, tt := range tests {
+		s := Majority(constSpecs(tt.n, tt.k)...)
+		if got := s.IsSatisfiedBy(u); got != tt.want {
+			t.Errorf("%s: IsSatisfiedBy = %v, want %v", tt.name, got, tt.want)
+		}
+		if got := Evaluate(s, u).Ok; got != tt.want {
+			t.Errorf("%s: Evaluate = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,330 @@
+package main
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// ContextSpecification is a specification whose evaluation may block (I/O, remote calls)
+// and therefore respects the context
+type ContextSpecification interface {
+	IsSatisfiedByContext(ctx context.Context, u *User) (bool, error)
+}
+
+// Contextual adapts a specification to ContextSpecification
+func Contextual(spec SpecificationUser) ContextSpecification {
+	return &contextualSpecification{spec: spec}
+}
+
+type contextualSpecification struct {
+	spec SpecificationUser
+}
+
+func (s *contextualSpecification) IsSatisfiedByContext(ctx context.Context, u *User) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+	return s.spec.IsSatisfiedBy(u), nil
+}
+
+// AndParallel evaluates every child concurrently, the first false cancels the siblings
+type AndParallelSpecification struct {
+	specs []ContextSpecification
+}
+
+func AndParallel(specs ...ContextSpecification) *AndParallelSpecification {
+	return &AndParallelSpecification{
+		specs: specs,
+	}
+}
+
+func (s *AndParallelSpecification) IsSatisfiedByContext(ctx context.Context, u *User) (bool, error) {
+	return evaluateParallel(ctx, s.specs, u, false)
+}
+
+// OrParallel evaluates every child concurrently, the first true cancels the siblings
+type OrParallelSpecification struct {
+	specs []ContextSpecification
+}
+
+func OrParallel(specs ...ContextSpecification) *OrParallelSpecification {
+	return &OrParallelSpecification{
+		specs: specs,
+	}
+}
+
+func (s *OrParallelSpecification) IsSatisfiedByContext(ctx context.Context, u *User) (bool, error) {
+	return evaluateParallel(ctx, s.specs, u, true)
+}
+
+// evaluateParallel returns decisive as soon as a child returns it, otherwise !decisive.
+// The siblings are canceled and waited for, so no goroutine outlives the call.
+func evaluateParallel(ctx context.Context, specs []ContextSpecification, u *User, decisive bool) (bool, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	type result struct {
+		ok  bool
+		err error
+	}
+	results := make(chan result, len(specs))
+	var wg sync.WaitGroup
+	defer func() {
+		cancel()
+		wg.Wait()
+	}()
+	for _, spec := range specs {
+		wg.Add(1)
+		go func(spec ContextSpecification) {
+			defer wg.Done()
+			ok, err := spec.IsSatisfiedByContext(ctx, u)
+			results <- result{ok: ok, err: err}
+		}(spec)
+	}
+	for range specs {
+		r := <-results
+		if r.err != nil {
+			return false, r.err
+		}
+		if r.ok == decisive {
+			return decisive, nil
+		}
+	}
+	return !decisive, nil
+}
+
+// EvaluateContext evaluates the specification checking the context before every node,
+// so a cancellation stops the traversal between two children even inside a large Or.
+// Leaves implementing ContextSpecification receive the context.
+func EvaluateContext(ctx context.Context, spec SpecificationUser, u *User) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+	switch s := spec.(type) {
+	case *AndSpecification:
+		return evaluateChildrenContext(ctx, s.specs, u, false)
+	case *OrSpecification:
+		return evaluateChildrenContext(ctx, s.specs, u, true)
+	case *NoneOfSpecification:
+		ok, err := evaluateChildrenContext(ctx, s.specs, u, true)
+		return !ok && err == nil, err
+	case *NotSpecification:
+		ok, err := EvaluateContext(ctx, s.spec, u)
+		return !ok && err == nil, err
+	}
+	return evaluateLeafContext(ctx, spec, u)
+}
+
+// evaluateResultContext evaluates the tree like Evaluate, every node without
+// short-circuit, with the leaves evaluated like EvaluateContext through the ScopedMemo
+// of the context if any
+func evaluateResultContext(ctx context.Context, spec SpecificationUser, u *User) (*Result, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	r := &Result{
+		Spec: spec,
+		Name: specName(spec),
+	}
+	var children []SpecificationUser
+	switch s := spec.(type) {
+	case *AndSpecification:
+		children = s.specs
+	case *OrSpecification:
+		children = s.specs
+	case *NotSpecification:
+		children = []SpecificationUser{s.spec}
+	case combiner:
+		children = s.Children()
+	default:
+		ok, err := evaluateLeafContext(ctx, spec, u)
+		if err != nil {
+			return nil, err
+		}
+		r.Ok = ok
+		return r, nil
+	}
+	results := make([]bool, len(children))
+	for i, child := range children {
+		cr, err := evaluateResultContext(ctx, child, u)
+		if err != nil {
+			return nil, err
+		}
+		results[i] = cr.Ok
+		r.Children = append(r.Children, cr)
+	}
+	switch s := spec.(type) {
+	case *AndSpecification:
+		r.Ok = !containsBool(results, false)
+	case *OrSpecification:
+		r.Ok = containsBool(results, true)
+	case *NotSpecification:
+		r.Ok = !results[0]
+	case combiner:
+		r.Ok = s.combine(results)
+	}
+	return r, nil
+}
+
+func containsBool(values []bool, v bool) bool {
+	for _, value := range values {
+		if value == v {
+			return true
+		}
+	}
+	return false
+}
+
+// resultReasons works like explainReasons on an evaluated tree: the reasons of And and
+// NoneOf come from the results of their children, which are not evaluated again
+func resultReasons(r *Result, u *User) []reason {
+	if r.Ok {
+		return nil
+	}
+	switch r.Spec.(type) {
+	case *AndSpecification:
+		var reasons []reason
+		for _, child := range r.Children {
+			reasons = append(reasons, resultReasons(child, u)...)
+		}
+		return reasons
+	case *NoneOfSpecification:
+		var reasons []reason
+		for _, child := range r.Children {
+			if child.Ok {
+				reasons = append(reasons, newReason(CodeForbidden, specString(child.Spec)))
+			}
+		}
+		return reasons
+	case Explainer:
+		return explainReasons(r.Spec, u)
+	}
+	return []reason{newReason(CodeNotSatisfied, specString(r.Spec))}
+}
+
+type scopedMemoKey struct{}
+
+// scopedMemo holds the results of the leaves for the scope of a request
+type scopedMemo struct {
+	mu      sync.Mutex
+	results map[scopedMemoEntry]bool
+}
+
+type scopedMemoEntry struct {
+	spec SpecificationUser
+	user *User
+}
+
+// ScopedMemo returns a context memoizing the results of the leaves evaluated by
+// EvaluateContext within it, per leaf and user pointer, so the leaves shared by
+// several checks of one request are evaluated once. Errors are not memoized, and
+// neither are the leaves that are not pointers. A user changed during the request
+// keeps the results computed before the change.
+func ScopedMemo(ctx context.Context) context.Context {
+	return context.WithValue(ctx, scopedMemoKey{}, &scopedMemo{
+		results: make(map[scopedMemoEntry]bool),
+	})
+}
+
+// evaluateLeafContext evaluates the leaf through the memo of ScopedMemo if any
+func evaluateLeafContext(ctx context.Context, spec SpecificationUser, u *User) (bool, error) {
+	m, _ := ctx.Value(scopedMemoKey{}).(*scopedMemo)
+	if m == nil || reflect.ValueOf(spec).Kind() != reflect.Ptr {
+		return evaluateLeaf(ctx, spec, u)
+	}
+	key := scopedMemoEntry{spec: spec, user: u}
+	m.mu.Lock()
+	ok, found := m.results[key]
+	m.mu.Unlock()
+	if found {
+		return ok, nil
+	}
+	ok, err := evaluateLeaf(ctx, spec, u)
+	if err != nil {
+		return false, err
+	}
+	m.mu.Lock()
+	m.results[key] = ok
+	m.mu.Unlock()
+	return ok, nil
+}
+
+func evaluateLeaf(ctx context.Context, spec SpecificationUser, u *User) (bool, error) {
+	if s, ok := spec.(ContextSpecification); ok {
+		return s.IsSatisfiedByContext(ctx, u)
+	}
+	return spec.IsSatisfiedBy(u), nil
+}
+
+// evaluateChildrenContext returns decisive as soon as a child returns it, otherwise !decisive
+func evaluateChildrenContext(ctx context.Context, specs []SpecificationUser, u *User, decisive bool) (bool, error) {
+	for _, spec := range specs {
+		ok, err := EvaluateContext(ctx, spec, u)
+		if err != nil {
+			return false, err
+		}
+		if ok == decisive {
+			return decisive, nil
+		}
+	}
+	return !decisive, nil
+}
+
+// NameUniqueInStream: no name received from the channel equals the name of the user
+// ignoring case. It reads until the first collision (false) or until the channel is
+// closed (true), a done context stops the reading with its error. The channel is
+// consumed, so the specification checks a single candidate: another evaluation
+// continues from where the previous one stopped.
+type NameUniqueInStreamSpecification struct {
+	names <-chan string
+}
+
+func NameUniqueInStream(names <-chan string) *NameUniqueInStreamSpecification {
+	return &NameUniqueInStreamSpecification{
+		names: names,
+	}
+}
+
+func (s *NameUniqueInStreamSpecification) IsSatisfiedByContext(ctx context.Context, u *User) (bool, error) {
+	for {
+		select {
+		case <-ctx.Done():
+			return false, ctx.Err()
+		case name, ok := <-s.names:
+			if !ok {
+				return true, nil
+			}
+			if strings.EqualFold(name, u.Name) {
+				return false, nil
+			}
+		}
+	}
+}
+
+// Bounded limits the number of concurrent evaluations of the specification,
+// e.g. to protect a downstream service. The callers over the limit wait for a slot
+// until their context is done.
+type BoundedSpecification struct {
+	spec  ContextSpecification
+	slots chan struct{}
+}
+
+func Bounded(spec ContextSpecification, maxConcurrent int) *BoundedSpecification {
+	if maxConcurrent < 1 {
+		maxConcurrent = 1
+	}
+	return &BoundedSpecification{
+		spec:  spec,
+		slots: make(chan struct{}, maxConcurrent),
+	}
+}
+
+func (s *BoundedSpecification) IsSatisfiedByContext(ctx context.Context, u *User) (bool, error) {
+	select {
+	case s.slots <- struct{}{}:
+	case <-ctx.Done():
+		return false, ctx.Err()
+	}
+	defer func() { <-s.slots }()
+	return s.spec.IsSatisfiedByContext(ctx, u)
+}
@@ -0,0 +1,380 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// instrumentedSpec returns ok after the delay, or the error of the context done first.
+// It counts its evaluations and the ones canceled.
+type instrumentedSpec struct {
+	ok              bool
+	delay           time.Duration
+	calls, canceled int32
+}
+
+func (s *instrumentedSpec) IsSatisfiedByContext(ctx context.Context, u *User) (bool, error) {
+	atomic.AddInt32(&s.calls, 1)
+	select {
+	case <-time.After(s.delay):
+		return s.ok, nil
+	case <-ctx.Done():
+		atomic.AddInt32(&s.canceled, 1)
+		return false, ctx.Err()
+	}
+}
+
+func TestParallelShortCircuit(t *testing.T) {
+	tests := []struct {
+		name  string
+		build func(fast, slow ContextSpecification) ContextSpecification
+		fast  bool
+	}{
+		{"AndParallel first false", func(fast, slow ContextSpecification) ContextSpecification { return AndParallel(slow, fast) }, false},
+		{"OrParallel first true", func(fast, slow ContextSpecification) ContextSpecification { return OrParallel(slow, fast) }, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fast := &instrumentedSpec{ok: tt.fast}
+			slow := &instrumentedSpec{ok: !tt.fast, delay: time.Hour}
+			start := time.Now()
+			ok, err := tt.build(fast, slow).IsSatisfiedByContext(context.Background(), &User{})
+			if err != nil || ok != tt.fast {
+				t.Fatalf("IsSatisfiedByContext = %v, %v, want %v", ok, err, tt.fast)
+			}
+			if elapsed := time.Since(start); elapsed > time.Second {
+				t.Errorf("waited %v for the slow child", elapsed)
+			}
+			// the siblings are waited for before returning
+			if atomic.LoadInt32(&slow.calls) != 1 || atomic.LoadInt32(&slow.canceled) != 1 {
+				t.Errorf("slow child: %d calls, %d canceled, want 1 and 1", slow.calls, slow.canceled)
+			}
+		})
+	}
+}
+
+func TestParallelAllChildren(t *testing.T) {
+	children := func(ok bool) []ContextSpecification {
+		return []ContextSpecification{
+			&instrumentedSpec{ok: ok, delay: time.Millisecond},
+			&instrumentedSpec{ok: ok},
+			Contextual(ValidNameNotAdmin),
+		}
+	}
+	u := &User{Name: "alexander"}
+	if ok, err := AndParallel(children(true)...).IsSatisfiedByContext(context.Background(), u); !ok || err != nil {
+		t.Errorf("AndParallel of true children = %v, %v", ok, err)
+	}
+	u.Locked = true
+	if ok, err := OrParallel(children(false)...).IsSatisfiedByContext(context.Background(), u); ok || err != nil {
+		t.Errorf("OrParallel of false children = %v, %v", ok, err)
+	}
+}
+
+func TestParallelCanceledContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := AndParallel(&instrumentedSpec{ok: true, delay: time.Hour}).IsSatisfiedByContext(ctx, &User{})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("error = %v, want context.Canceled", err)
+	}
+}
+
+// slowLeaf is a leaf taking the delay to evaluate, after which it runs the hook
+type slowLeaf struct {
+	delay time.Duration
+	hook  func()
+	calls int
+}
+
+func (s *slowLeaf) IsSatisfiedBy(u *User) bool {
+	s.calls++
+	time.Sleep(s.delay)
+	if s.hook != nil {
+		s.hook()
+	}
+	return false
+}
+
+func TestEvaluateContextCancelBetweenChildren(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	// the context is canceled while the first child is evaluated
+	first := &slowLeaf{delay: 10 * time.Millisecond, hook: cancel}
+	second := &slowLeaf{delay: time.Hour}
+	ok, err := EvaluateContext(ctx, Or(first, Not(Or(IsAdmin, second))), &User{})
+	if ok || !errors.Is(err, context.Canceled) {
+		t.Errorf("EvaluateContext = %v, %v, want false, context.Canceled", ok, err)
+	}
+	if first.calls != 1 || second.calls != 0 {
+		t.Errorf("children evaluated %d and %d times, want 1 and 0", first.calls, second.calls)
+	}
+}
+
+func TestEvaluateContext(t *testing.T) {
+	tests := []struct {
+		name string
+		spec SpecificationUser
+	}{
+		{"And", ValidNameNotAdmin},
+		{"Or", AnyAdmin},
+		{"NoneOf", NoneOf(IsAdmin, Locked)},
+		{"Not", Not(IsNameShort4)},
+		{"other composite", Majority(IsAdmin, Locked, IsNameShort4)},
+	}
+	for _, tt := range tests {
+		for _, u := range typeDomain() {
+			ok, err := EvaluateContext(context.Background(), tt.spec, u)
+			if err != nil || ok != tt.spec.IsSatisfiedBy(u) {
+				t.Errorf("%s: EvaluateContext(%+v) = %v, %v, want %v", tt.name, *u, ok, err, tt.spec.IsSatisfiedBy(u))
+			}
+		}
+	}
+}
+
+// nameStream returns a buffered channel of the names, closed if closed is set
+func nameStream(closed bool, names ...string) chan string {
+	ch := make(chan string, len(names))
+	for _, name := range names {
+		ch <- name
+	}
+	if closed {
+		close(ch)
+	}
+	return ch
+}
+
+func TestNameUniqueInStream(t *testing.T) {
+	tests := []struct {
+		name   string
+		stream chan string
+		want   bool
+		unread int
+	}{
+		{"early collision stops reading", nameStream(true, "foo", "BOO", "bar", "baz", "qux"), false, 3},
+		{"last name collides", nameStream(true, "foo", "boo"), false, 0},
+		{"clean stream", nameStream(true, "foo", "bar", "booo"), true, 0},
+		{"empty stream", nameStream(true), true, 0},
+	}
+	ctx := context.Background()
+	for _, tt := range tests {
+		ok, err := NameUniqueInStream(tt.stream).IsSatisfiedByContext(ctx, &User{Name: "boo"})
+		if ok != tt.want || err != nil {
+			t.Errorf("%s: IsSatisfiedByContext = %v, %v, want %v", tt.name, ok, err, tt.want)
+		}
+		if n := len(tt.stream); n != tt.unread {
+			t.Errorf("%s: %d names left unread, want %d", tt.name, n, tt.unread)
+		}
+	}
+}
+
+func TestNameUniqueInStreamCanceled(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	// the stream is never closed
+	ok, err := NameUniqueInStream(nameStream(false, "foo")).IsSatisfiedByContext(ctx, &User{Name: "boo"})
+	if ok || !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("IsSatisfiedByContext = %v, %v, want false, %v", ok, err, context.DeadlineExceeded)
+	}
+}
+
+// concurrencySpec records the maximum number of its evaluations running at once,
+// each evaluation waits for the release channel
+type concurrencySpec struct {
+	release          chan struct{}
+	running, maxSeen int32
+}
+
+func (s *concurrencySpec) IsSatisfiedByContext(ctx context.Context, u *User) (bool, error) {
+	n := atomic.AddInt32(&s.running, 1)
+	for {
+		seen := atomic.LoadInt32(&s.maxSeen)
+		if n <= seen || atomic.CompareAndSwapInt32(&s.maxSeen, seen, n) {
+			break
+		}
+	}
+	<-s.release
+	atomic.AddInt32(&s.running, -1)
+	return true, nil
+}
+
+func TestBoundedConcurrency(t *testing.T) {
+	const maxConcurrent, callers = 3, 10
+	inner := &concurrencySpec{release: make(chan struct{})}
+	s := Bounded(inner, maxConcurrent)
+	var wg sync.WaitGroup
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if ok, err := s.IsSatisfiedByContext(context.Background(), &User{}); !ok || err != nil {
+				t.Errorf("IsSatisfiedByContext = %v, %v", ok, err)
+			}
+		}()
+	}
+	// wait for the slots to fill up, give the queued callers time to overrun them
+	for atomic.LoadInt32(&inner.running) < maxConcurrent {
+		time.Sleep(time.Millisecond)
+	}
+	time.Sleep(10 * time.Millisecond)
+	for i := 0; i < callers; i++ {
+		inner.release <- struct{}{}
+	}
+	wg.Wait()
+	if n := atomic.LoadInt32(&inner.maxSeen); n != maxConcurrent {
+		t.Errorf("%d evaluations ran at once, want %d", n, maxConcurrent)
+	}
+}
+
+func TestBoundedCanceledWait(t *testing.T) {
+	inner := &concurrencySpec{release: make(chan struct{})}
+	s := Bounded(inner, 1)
+	busy := make(chan struct{})
+	go func() {
+		s.IsSatisfiedByContext(context.Background(), &User{})
+		close(busy)
+	}()
+	for atomic.LoadInt32(&inner.running) == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	ok, err := s.IsSatisfiedByContext(ctx, &User{})
+	if ok || !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("IsSatisfiedByContext waiting for a slot = %v, %v, want false, %v", ok, err, context.DeadlineExceeded)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("canceled wait returned after %v", elapsed)
+	}
+	inner.release <- struct{}{}
+	<-busy
+	if n := atomic.LoadInt32(&inner.maxSeen); n != 1 {
+		t.Errorf("the canceled caller was evaluated, %d evaluations at once", n)
+	}
+}
+
+func TestScopedMemoSharesLeaf(t *testing.T) {
+	expensive := &countingSpec{ok: true}
+	first, second := And(expensive, NotLocked), Or(IsAdmin, expensive)
+	boo, foo := &User{Name: "boo"}, &User{Name: "foo"}
+	tests := []struct {
+		name  string
+		ctx   context.Context
+		users []*User
+		calls int
+	}{
+		{"two evaluations in one scope", ScopedMemo(context.Background()), []*User{boo}, 1},
+		{"without a scope", context.Background(), []*User{boo}, 2},
+		{"per user", ScopedMemo(context.Background()), []*User{boo, foo}, 2},
+	}
+	for _, tt := range tests {
+		expensive.calls = 0
+		for _, u := range tt.users {
+			for _, spec := range []SpecificationUser{first, second} {
+				if ok, err := EvaluateContext(tt.ctx, spec, u); !ok || err != nil {
+					t.Fatalf("%s: EvaluateContext(%s) = %v, %v", tt.name, specString(spec), ok, err)
+				}
+			}
+		}
+		if expensive.calls != tt.calls {
+			t.Errorf("%s: shared leaf evaluated %d times, want %d", tt.name, expensive.calls, tt.calls)
+		}
+	}
+
+	// separate scopes do not share the results
+	expensive.calls = 0
+	EvaluateContext(ScopedMemo(context.Background()), first, boo)
+	EvaluateContext(ScopedMemo(context.Background()), first, boo)
+	if expensive.calls != 2 {
+		t.Errorf("shared leaf evaluated %d times in two scopes, want 2", expensive.calls)
+	}
+}
+
+func TestScopedMemoCheckAccess(t *testing.T) {
+	expensive := &countingSpec{ok: true}
+	handled := 0
+	checkRead := checkAccessContext(And(expensive, NotLocked), "read", func() { handled++ })
+	checkWrite := checkAccessContext(And(expensive, Not(IsNameShort4)), "write", func() { handled++ })
+	ctx := ScopedMemo(context.Background())
+	u := &User{Name: "alexander"}
+	for _, check := range []func(context.Context, *User) error{checkRead, checkWrite} {
+		if err := check(ctx, u); err != nil {
+			t.Fatalf("check: %v", err)
+		}
+	}
+	if expensive.calls != 1 || handled != 2 {
+		t.Errorf("shared leaf evaluated %d times with %d handlers run, want 1 and 2", expensive.calls, handled)
+	}
+}
+
+func TestCheckAccessContextDenialReasons(t *testing.T) {
+	failing := &countingSpec{ok: false}
+	passing := &countingSpec{ok: true}
+	forbidden := &countingSpec{ok: true}
+	spec := And(failing, passing, NoneOf(forbidden, IsAdmin), Not(IsNameShort4))
+	u := &User{Name: "boo"}
+	want := checkAccess(spec, "panel", func() {})(u).Error()
+	for _, ctx := range []context.Context{context.Background(), ScopedMemo(context.Background())} {
+		for _, leaf := range []*countingSpec{failing, passing, forbidden} {
+			leaf.calls = 0
+		}
+		err := checkAccessContext(spec, "panel", func() {})(ctx, u)
+		if err == nil || err.Error() != want {
+			t.Errorf("error = %v, want %s", err, want)
+		}
+		for _, leaf := range []*countingSpec{failing, passing, forbidden} {
+			if leaf.calls != 1 {
+				t.Errorf("%v leaf evaluated %d times for a denial, want 1", leaf.ok, leaf.calls)
+			}
+		}
+	}
+	// the leaves evaluated by an earlier check of the scope are not evaluated again
+	ctx := ScopedMemo(context.Background())
+	failing.calls = 0
+	EvaluateContext(ctx, failing, u)
+	checkAccessContext(spec, "panel", func() {})(ctx, u)
+	if failing.calls != 1 {
+		t.Errorf("leaf evaluated %d times in one scope, want 1", failing.calls)
+	}
+}
+
+// failingOnce fails its first evaluation and passes the next ones
+type failingOnce struct {
+	calls int
+}
+
+func (s *failingOnce) IsSatisfiedBy(u *User) bool {
+	return true
+}
+
+func (s *failingOnce) IsSatisfiedByContext(ctx context.Context, u *User) (bool, error) {
+	s.calls++
+	if s.calls == 1 {
+		return false, errors.New("unavailable")
+	}
+	return true, nil
+}
+
+func TestScopedMemoSkipsErrors(t *testing.T) {
+	leaf := &failingOnce{}
+	ctx := ScopedMemo(context.Background())
+	u := &User{}
+	if _, err := EvaluateContext(ctx, leaf, u); err == nil {
+		t.Fatalf("first evaluation returned no error")
+	}
+	for i := 0; i < 2; i++ {
+		if ok, err := EvaluateContext(ctx, leaf, u); !ok || err != nil {
+			t.Errorf("EvaluateContext after the error = %v, %v, want true", ok, err)
+		}
+	}
+	// the error was not memoized, the success was
+	if leaf.calls != 2 {
+		t.Errorf("leaf evaluated %d times, want 2", leaf.calls)
+	}
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// CountryIn: the user country (ISO 3166-1 alpha-2, case-insensitive) is one of the codes.
+// With no codes nobody is satisfied.
+type CountrySpecification struct {
+	codes map[string]struct{}
+	not   bool
+}
+
+func CountryIn(codes ...string) (*CountrySpecification, error) {
+	return newCountrySpecification(codes, false)
+}
+
+// CountryNotIn: the user country is none of the codes, with no codes everybody is satisfied
+func CountryNotIn(codes ...string) (*CountrySpecification, error) {
+	return newCountrySpecification(codes, true)
+}
+
+func newCountrySpecification(codes []string, not bool) (*CountrySpecification, error) {
+	s := &CountrySpecification{
+		codes: make(map[string]struct{}, len(codes)),
+		not:   not,
+	}
+	for _, code := range codes {
+		if !isCountryCode(code) {
+			return nil, fmt.Errorf("country: invalid ISO 3166-1 alpha-2 code %q", code)
+		}
+		s.codes[strings.ToUpper(code)] = struct{}{}
+	}
+	return s, nil
+}
+
+func isCountryCode(code string) bool {
+	if len(code) != 2 {
+		return false
+	}
+	for _, c := range code {
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z') {
+			return false
+		}
+	}
+	return true
+}
+
+func (s *CountrySpecification) IsSatisfiedBy(u *User) bool {
+	_, ok := s.codes[strings.ToUpper(u.Country)]
+	return ok != s.not
+}
+
+func (s *CountrySpecification) String() string {
+	codes := make([]string, 0, len(s.codes))
+	for code := range s.codes {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+	name := "CountryIn"
+	if s.not {
+		name = "CountryNotIn"
+	}
+	return name + "(" + strings.Join(codes, ", ") + ")"
+}
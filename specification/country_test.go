@@ -0,0 +1,53 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestCountryIn(t *testing.T) {
+	in, err := CountryIn("us", "Ca")
+	if err != nil {
+		t.Fatal(err)
+	}
+	RunSpecTests(t, in, []SpecCase{
+		{Name: "lower-case code matches", User: &User{Country: "US"}, Expected: true},
+		{Name: "lower-case country", User: &User{Country: "ca"}, Expected: true},
+		{Name: "other country", User: &User{Country: "GB"}, Expected: false},
+		{Name: "no country", User: &User{}, Expected: false},
+	})
+	notIn, err := CountryNotIn("US")
+	if err != nil {
+		t.Fatal(err)
+	}
+	RunSpecTests(t, notIn, []SpecCase{
+		{Name: "excluded country", User: &User{Country: "us"}, Expected: false},
+		{Name: "other country", User: &User{Country: "GB"}, Expected: true},
+	})
+	if got, want := in.String(), "CountryIn(CA, US)"; got != want {
+		t.Errorf("String = %s, want %s", got, want)
+	}
+}
+
+func TestCountryEmptySet(t *testing.T) {
+	in, _ := CountryIn()
+	notIn, _ := CountryNotIn()
+	u := &User{Country: "US"}
+	if in.IsSatisfiedBy(u) {
+		t.Errorf("CountryIn() satisfied")
+	}
+	if !notIn.IsSatisfiedBy(u) {
+		t.Errorf("CountryNotIn() not satisfied")
+	}
+}
+
+func TestCountryInvalidCode(t *testing.T) {
+	for _, code := range []string{"USA", "u", "", "1A", "ü1"} {
+		s, err := CountryIn("US", code)
+		if err == nil || s != nil {
+			t.Errorf("CountryIn(%q) = %v, %v, want an error", code, s, err)
+		}
+	}
+	if _, err := CountryNotIn("USA"); err == nil || err.Error() != `country: invalid ISO 3166-1 alpha-2 code "USA"` {
+		t.Errorf("CountryNotIn(USA) error = %v", err)
+	}
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// DecodeRules reads a JSON object of rule names and Parse expressions
+//
+//	{"validUser": "notAdmin AND NOT isNameShort4", "blocked": "locked"}
+//
+// one rule at a time, so the whole file is never loaded into memory.
+// The error of a malformed rule names it.
+func DecodeRules(r io.Reader) (map[string]SpecificationUser, error) {
+	dec := json.NewDecoder(r)
+	if err := expectDelim(dec, '{'); err != nil {
+		return nil, err
+	}
+	rules := make(map[string]SpecificationUser)
+	for dec.More() {
+		t, err := dec.Token()
+		if err != nil {
+			return nil, fmt.Errorf("decode rules: %w", err)
+		}
+		name, ok := t.(string)
+		if !ok {
+			return nil, fmt.Errorf("decode rules: unexpected %v", t)
+		}
+		var expr string
+		if err := dec.Decode(&expr); err != nil {
+			return nil, fmt.Errorf("decode rules: rule %q: %w", name, err)
+		}
+		spec, err := Parse(expr)
+		if err != nil {
+			return nil, fmt.Errorf("decode rules: rule %q: %w", name, err)
+		}
+		rules[name] = spec
+	}
+	if err := expectDelim(dec, '}'); err != nil {
+		return nil, err
+	}
+	return rules, nil
+}
+
+func expectDelim(dec *json.Decoder, delim json.Delim) error {
+	t, err := dec.Token()
+	if err != nil {
+		return fmt.Errorf("decode rules: %w", err)
+	}
+	if t != delim {
+		return fmt.Errorf("decode rules: expected %v, got %v", delim, t)
+	}
+	return nil
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func TestDecodeRules(t *testing.T) {
+	input := `{
+		"validUser": "notAdmin AND NOT isNameShort4",
+		"blocked": "locked",
+		"staff": "anyAdmin OR (isPersonal AND NOT locked)"
+	}`
+	rules, err := DecodeRules(iotest.OneByteReader(strings.NewReader(input)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{
+		"validUser": "And(Not(Or(Type(ADMIN), Type(SUPER ADMIN))), Not(NameShort(4)))",
+		"blocked":   "Locked",
+		"staff":     "Or(Or(Type(ADMIN), Type(SUPER ADMIN)), And(Type(PERSONAL), Not(Locked)))",
+	}
+	if len(rules) != len(want) {
+		t.Errorf("DecodeRules returned %d rules, want %d", len(rules), len(want))
+	}
+	for name, s := range want {
+		if got := specString(rules[name]); got != s {
+			t.Errorf("rule %s = %s, want %s", name, got, s)
+		}
+	}
+}
+
+func TestDecodeRulesErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		err   string
+	}{
+		{"malformed expression in the middle", `{"a": "isAdmin", "b": "isAdmin AND", "c": "locked"}`,
+			`decode rules: rule "b": parse: unexpected end of expression`},
+		{"not a string in the middle", `{"a": "isAdmin", "b": 42, "c": "locked"}`,
+			`decode rules: rule "b": json: cannot unmarshal number into Go value of type string`},
+		{"not an object", `["isAdmin"]`, "decode rules: expected {, got ["},
+		{"truncated", `{"a": "isAdmin"`, "decode rules: unexpected end of JSON input"},
+		{"empty", ``, "decode rules: EOF"},
+	}
+	for _, tt := range tests {
+		rules, err := DecodeRules(strings.NewReader(tt.input))
+		if err == nil || err.Error() != tt.err || rules != nil {
+			t.Errorf("%s: DecodeRules = %v, %v, want the error %s", tt.name, rules, err, tt.err)
+		}
+	}
+}
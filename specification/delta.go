@@ -0,0 +1,53 @@
+package main
+
+import (
+	"sort"
+	"strings"
+)
+
+// DeltaSpecification is a specification over a change of the user, e.g. an update
+type DeltaSpecification interface {
+	IsSatisfiedByDelta(prev, curr *User) bool
+}
+
+type typeTransition struct {
+	from, to UserType
+}
+
+// ValidPromotion: the type change is allowed by the transition table,
+// keeping the same type is always allowed
+type PromotionSpecification struct {
+	allowed map[typeTransition]struct{}
+}
+
+// ValidPromotion allows the standard ladder Personal -> Admin -> SuperAdmin
+func ValidPromotion() *PromotionSpecification {
+	return (&PromotionSpecification{
+		allowed: make(map[typeTransition]struct{}),
+	}).
+		AllowTransition(Personal, Admin).
+		AllowTransition(Admin, SuperAdmin)
+}
+
+// AllowTransition adds the transition to the table
+func (s *PromotionSpecification) AllowTransition(from, to UserType) *PromotionSpecification {
+	s.allowed[typeTransition{from: from, to: to}] = struct{}{}
+	return s
+}
+
+func (s *PromotionSpecification) IsSatisfiedByDelta(prev, curr *User) bool {
+	if prev.Type == curr.Type {
+		return true
+	}
+	_, ok := s.allowed[typeTransition{from: prev.Type, to: curr.Type}]
+	return ok
+}
+
+func (s *PromotionSpecification) String() string {
+	transitions := make([]string, 0, len(s.allowed))
+	for t := range s.allowed {
+		transitions = append(transitions, t.from.String()+" -> "+t.to.String())
+	}
+	sort.Strings(transitions)
+	return "ValidPromotion(" + strings.Join(transitions, ", ") + ")"
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestValidPromotion(t *testing.T) {
+	s := ValidPromotion()
+	tests := []struct {
+		from, to UserType
+		want     bool
+	}{
+		{Personal, Admin, true},
+		{Admin, SuperAdmin, true},
+		{Personal, SuperAdmin, false},
+		{SuperAdmin, Admin, false},
+		{Admin, Personal, false},
+		{Personal, Personal, true},
+		{SuperAdmin, SuperAdmin, true},
+	}
+	for _, tt := range tests {
+		if got := s.IsSatisfiedByDelta(&User{Type: tt.from}, &User{Type: tt.to}); got != tt.want {
+			t.Errorf("%s -> %s allowed = %v, want %v", tt.from, tt.to, got, tt.want)
+		}
+	}
+	s.AllowTransition(SuperAdmin, Personal)
+	if !s.IsSatisfiedByDelta(&User{Type: SuperAdmin}, &User{Type: Personal}) {
+		t.Errorf("the added transition is not allowed")
+	}
+	want := "ValidPromotion(ADMIN -> SUPER ADMIN, PERSONAL -> ADMIN, SUPER ADMIN -> PERSONAL)"
+	if got := s.String(); got != want {
+		t.Errorf("String = %s, want %s", got, want)
+	}
+}
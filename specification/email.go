@@ -0,0 +1,46 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// EmailLocalMatches: the local part of the email (before @) matches the pattern,
+// e.g. `^[^+]+$` forbids plus-addressing. A malformed email is not satisfied.
+type EmailLocalSpecification struct {
+	re *regexp.Regexp
+}
+
+func EmailLocalMatches(pattern string) (*EmailLocalSpecification, error) {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("EmailLocalMatches: %w", err)
+	}
+	return &EmailLocalSpecification{
+		re: re,
+	}, nil
+}
+
+func (s *EmailLocalSpecification) IsSatisfiedBy(u *User) bool {
+	local, ok := emailLocalPart(u.Email)
+	return ok && s.re.MatchString(local)
+}
+
+func (s *EmailLocalSpecification) String() string {
+	return fmt.Sprintf("EmailLocalMatches(%s)", s.re)
+}
+
+func (s *EmailLocalSpecification) hashKey() string {
+	return s.re.String()
+}
+
+// emailLocalPart returns the part before @ of an email with exactly one @
+// and non-empty local and domain parts
+func emailLocalPart(email string) (string, bool) {
+	parts := strings.Split(email, "@")
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", false
+	}
+	return parts[0], true
+}
@@ -0,0 +1,32 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestEmailLocalMatches(t *testing.T) {
+	s, err := EmailLocalMatches(`^[^+]+$`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	RunSpecTests(t, s, []SpecCase{
+		{Name: "plain address", User: &User{Email: "user@x.com"}, Expected: true},
+		{Name: "plus-addressing", User: &User{Email: "user+tag@x.com"}, Expected: false},
+		{Name: "plus in the domain only", User: &User{Email: "user@x+y.com"}, Expected: true},
+		{Name: "no @", User: &User{Email: "user.x.com"}, Expected: false},
+		{Name: "two @", User: &User{Email: "user@x@x.com"}, Expected: false},
+		{Name: "empty local part", User: &User{Email: "@x.com"}, Expected: false},
+		{Name: "empty domain", User: &User{Email: "user@"}, Expected: false},
+		{Name: "no email", User: &User{}, Expected: false},
+	})
+}
+
+func TestEmailLocalMatchesInvalidPattern(t *testing.T) {
+	s, err := EmailLocalMatches(`[a-`)
+	if err == nil || s != nil {
+		t.Fatalf("EmailLocalMatches([a-) = %v, %v, want an error", s, err)
+	}
+	if want := "EmailLocalMatches: error parsing regexp: missing closing ]: `[a-`"; err.Error() != want {
+		t.Errorf("error = %q, want %q", err, want)
+	}
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// Explainer is implemented by specifications that can report why a user
+// does not satisfy them.
+type Explainer interface {
+	// Explain returns the reasons of the failure, empty if the user satisfies the specification
+	Explain(u *User) []string
+}
+
+// Explain returns the reasons why the user does not satisfy the specification.
+// Specifications that do not implement Explainer get a single generic reason.
+func Explain(spec SpecificationUser, u *User) []string {
+	return reasonMessages(explainReasons(spec, u))
+}
+
+// reason is an explanation message along with the code it was rendered from
+type reason struct {
+	code    string
+	message string
+}
+
+func newReason(code string, args ...interface{}) reason {
+	return reason{code: code, message: message(code, args...)}
+}
+
+// reasoner is implemented by the Explainers of the package, their Explain renders reasons
+type reasoner interface {
+	reasons(u *User) []reason
+}
+
+// explainReasons works like Explain but keeps the codes, the reasons of an Explainer
+// outside of the package have no code
+func explainReasons(spec SpecificationUser, u *User) []reason {
+	switch s := spec.(type) {
+	case reasoner:
+		return s.reasons(u)
+	case Explainer:
+		var reasons []reason
+		for _, m := range s.Explain(u) {
+			reasons = append(reasons, reason{message: m})
+		}
+		return reasons
+	}
+	if spec.IsSatisfiedBy(u) {
+		return nil
+	}
+	return []reason{newReason(CodeNotSatisfied, specString(spec))}
+}
+
+func reasonMessages(reasons []reason) []string {
+	if reasons == nil {
+		return nil
+	}
+	messages := make([]string, len(reasons))
+	for i, r := range reasons {
+		messages[i] = r.message
+	}
+	return messages
+}
+
+// Codes of the explanation messages
+const (
+	CodeNotSatisfied = "not_satisfied"
+	CodeFieldInvalid = "field_invalid"
+	CodeForbidden    = "forbidden_condition"
+
+	CodeNameTooShort     = "name_too_short"
+	CodeNameTooLong      = "name_too_long"
+	CodeNameInvalidChars = "name_invalid_chars"
+	CodeNameLeadingDigit = "name_leading_digit"
+	CodeNameReserved     = "name_reserved"
+)
+
+// Localizer renders the explanation messages by their code and arguments
+type Localizer interface {
+	Message(code string, args ...interface{}) string
+}
+
+var englishMessages = map[string]string{
+	CodeNotSatisfied: "%s: not satisfied",
+	CodeFieldInvalid: "field %s: invalid value",
+	CodeForbidden:    "forbidden condition %s",
+
+	CodeNameTooShort:     "name: shorter than %d characters",
+	CodeNameTooLong:      "name: longer than %d characters",
+	CodeNameInvalidChars: "name: does not match %s",
+	CodeNameLeadingDigit: "name: starts with a digit",
+	CodeNameReserved:     "name: %s is reserved",
+}
+
+type englishLocalizer struct{}
+
+func (englishLocalizer) Message(code string, args ...interface{}) string {
+	format, ok := englishMessages[code]
+	if !ok {
+		// Sprintln separates every argument, unlike Sprint between strings
+		return code + ": " + strings.TrimSuffix(fmt.Sprintln(args...), "\n")
+	}
+	return fmt.Sprintf(format, args...)
+}
+
+var (
+	localizerMu sync.RWMutex
+	localizer   Localizer = englishLocalizer{}
+)
+
+// SetLocalizer sets the localizer of the explanations, nil restores English
+func SetLocalizer(l Localizer) {
+	if l == nil {
+		l = englishLocalizer{}
+	}
+	localizerMu.Lock()
+	localizer = l
+	localizerMu.Unlock()
+}
+
+func message(code string, args ...interface{}) string {
+	localizerMu.RLock()
+	l := localizer
+	localizerMu.RUnlock()
+	return l.Message(code, args...)
+}
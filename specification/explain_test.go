@@ -0,0 +1,48 @@
+package main
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// upperLocalizer renders a message as its code in upper case followed by the arguments
+type upperLocalizer struct{}
+
+func (upperLocalizer) Message(code string, args ...interface{}) string {
+	return strings.ToUpper(code) + fmt.Sprint(args...)
+}
+
+func TestExplainLocalizer(t *testing.T) {
+	SetLocalizer(upperLocalizer{})
+	defer SetLocalizer(nil)
+
+	tests := []struct {
+		name    string
+		spec    SpecificationUser
+		reasons []string
+	}{
+		{"generic", IsAdmin, []string{"NOT_SATISFIEDType(ADMIN)"}},
+		{"NoneOf", NoneOf(Locked), []string{"FORBIDDEN_CONDITIONLocked"}},
+		{"Requires", Requires(map[string]func(*User) bool{"email": func(u *User) bool { return false }}), []string{"FIELD_INVALIDemail"}},
+		{"SatisfiesPolicy", SatisfiesPolicy(UsernamePolicy{MinLength: 5}), []string{"NAME_TOO_SHORT5"}},
+	}
+	u := &User{Name: "boo", Locked: true}
+	for _, tt := range tests {
+		if got := Explain(tt.spec, u); !reflect.DeepEqual(got, tt.reasons) {
+			t.Errorf("%s: Explain = %q, want %q", tt.name, got, tt.reasons)
+		}
+	}
+
+	SetLocalizer(nil)
+	if got, want := Explain(IsAdmin, u), []string{"Type(ADMIN): not satisfied"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Explain after restoring English = %q, want %q", got, want)
+	}
+}
+
+func TestEnglishLocalizerUnknownCode(t *testing.T) {
+	if got, want := (englishLocalizer{}).Message("custom_code", "x", 1), "custom_code: x 1"; got != want {
+		t.Errorf("Message = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/antonmedv/expr"
+	"github.com/antonmedv/expr/vm"
+)
+
+// Expr compiles a boolean expression over the user with the expr language
+// (github.com/antonmedv/expr, the module expr-lang/expr was published as before v1.15):
+//
+//	type == "ADMIN" && !locked && len(name) >= 4
+//
+// The variables are name and type (strings) and locked (bool), the builtin type() is
+// disabled so that type names the variable. Everything else is the language of the
+// library, e.g. len(s) is the byte length of a string, not its rune count.
+// The expression is compiled once at construction, unknown variables and type
+// errors are compile errors. An expression that is not boolean, e.g. len(name),
+// is never satisfied.
+type ExprSpecification struct {
+	expr    string
+	program *vm.Program `hash:"-"`
+}
+
+// exprEnv holds the variables of the expressions
+type exprEnv struct {
+	Name   string `expr:"name"`
+	Type   string `expr:"type"`
+	Locked bool   `expr:"locked"`
+}
+
+func Expr(expression string) (*ExprSpecification, error) {
+	program, err := expr.Compile(expression, expr.Env(exprEnv{}), expr.DisableBuiltin("type"))
+	if err != nil {
+		return nil, fmt.Errorf("expr: %w", err)
+	}
+	return &ExprSpecification{
+		expr:    expression,
+		program: program,
+	}, nil
+}
+
+func (s *ExprSpecification) IsSatisfiedBy(u *User) bool {
+	out, err := expr.Run(s.program, exprEnv{
+		Name:   u.Name,
+		Type:   u.Type.String(),
+		Locked: u.Locked,
+	})
+	ok, isBool := out.(bool)
+	return err == nil && isBool && ok
+}
+
+func (s *ExprSpecification) String() string {
+	return fmt.Sprintf("Expr(%s)", s.expr)
+}
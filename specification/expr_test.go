@@ -0,0 +1,117 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestExpr(t *testing.T) {
+	tests := []struct {
+		expr  string
+		cases []SpecCase
+	}{
+		{`type == "ADMIN" && !locked && len(name) >= 4`, []SpecCase{
+			{User: &User{Type: Admin, Name: "alexander"}, Expected: true},
+			{User: &User{Type: Admin, Name: "alex"}, Expected: true},
+			{User: &User{Type: Admin, Name: "boo"}, Expected: false},
+			{User: &User{Type: Admin, Name: "alexander", Locked: true}, Expected: false},
+			{User: &User{Type: SuperAdmin, Name: "alexander"}, Expected: false},
+		}},
+		{`locked || type == "ADMIN" && false`, []SpecCase{
+			{Name: "&& binds tighter", User: &User{Type: Admin}, Expected: false},
+			{User: &User{Type: Personal, Locked: true}, Expected: true},
+		}},
+		{`(locked || type == "ADMIN") && !false`, []SpecCase{
+			{User: &User{Type: Admin}, Expected: true},
+			{User: &User{Type: Personal}, Expected: false},
+		}},
+		{`!!locked == true`, []SpecCase{
+			{User: &User{Locked: true}, Expected: true},
+			{User: &User{}, Expected: false},
+		}},
+		{`name != "root" && name > "b" && name <= "foo"`, []SpecCase{
+			{User: &User{Name: "boo"}, Expected: true},
+			{User: &User{Name: "foo"}, Expected: true},
+			{User: &User{Name: "alexander"}, Expected: false},
+			{User: &User{Name: "root"}, Expected: false},
+		}},
+		{`len(name) < 2.5 || len(name) > 4`, []SpecCase{
+			{User: &User{Name: "ab"}, Expected: true},
+			{User: &User{Name: "boo"}, Expected: false},
+			{User: &User{Name: "alexander"}, Expected: true},
+		}},
+		{`len(name) == 8`, []SpecCase{
+			{Name: "bytes, not runes", User: &User{Name: "ёжик"}, Expected: true},
+			{User: &User{Name: "boo"}, Expected: false},
+		}},
+		{`name in ["boo", "foo"] || name matches "^al" && type startsWith "SUPER"`, []SpecCase{
+			{Name: "in", User: &User{Name: "foo"}, Expected: true},
+			{Name: "matches and startsWith", User: &User{Name: "alex", Type: SuperAdmin}, Expected: true},
+			{User: &User{Name: "alex", Type: Admin}, Expected: false},
+		}},
+		{`name == "a \"b\" && c"`, []SpecCase{
+			{Name: "escaped string literal", User: &User{Name: `a "b" && c`}, Expected: true},
+			{User: &User{Name: "a"}, Expected: false},
+		}},
+		{`len(name)`, []SpecCase{
+			{Name: "number is never satisfied", User: &User{Name: "boo"}, Expected: false},
+		}},
+		{`name`, []SpecCase{
+			{Name: "string is never satisfied", User: &User{Name: "true"}, Expected: false},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			spec, err := Expr(tt.expr)
+			if err != nil {
+				t.Fatalf("Expr: %v", err)
+			}
+			if got, want := spec.String(), "Expr("+tt.expr+")"; got != want {
+				t.Errorf("String = %s, want %s", got, want)
+			}
+			RunSpecTests(t, spec, tt.cases)
+		})
+	}
+}
+
+func TestExprErrors(t *testing.T) {
+	tests := []struct {
+		expr string
+		err  string
+	}{
+		{"", "expr: unexpected token EOF (1:1)"},
+		{"name ==", "expr: unexpected token EOF (1:7)"},
+		{`name == "abc`, "expr: literal not terminated (1:13)"},
+		{"age > 3", "expr: unknown name age (1:1)"},
+		{"name == 1", "expr: invalid operation: == (mismatched types string and int) (1:6)"},
+		{"locked && name", "expr: invalid operation: && (mismatched types bool and string) (1:8)"},
+		{"!name", "expr: invalid operation: ! (mismatched type string) (1:1)"},
+		{"(locked", "expr: unexpected token EOF (1:7)"},
+		{"len(locked)", "expr: invalid argument for len (type bool) (1:1)"},
+		{`type(name) == "string"`, "expr: string is not callable (1:1)"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			spec, err := Expr(tt.expr)
+			// the library follows the message with the expression and a caret
+			if err == nil || strings.SplitN(err.Error(), "\n", 2)[0] != tt.err {
+				t.Errorf("Expr error = %v, want %s", err, tt.err)
+			}
+			if spec != nil {
+				t.Errorf("Expr returned %s with the error", spec)
+			}
+		})
+	}
+}
+
+func TestExprHash(t *testing.T) {
+	a, _ := Expr("!locked")
+	b, _ := Expr("!locked")
+	c, _ := Expr("locked")
+	if Hash(a) != Hash(b) {
+		t.Errorf("Hash of two compilations of one expression differs")
+	}
+	if Hash(a) == Hash(c) {
+		t.Errorf("Hash(%s) = Hash(%s)", a, c)
+	}
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"sort"
+	"strings"
+)
+
+// Requires: every named field predicate must pass
+type RequiresSpecification struct {
+	fields map[string]func(*User) bool
+	names  []string
+}
+
+func Requires(fields map[string]func(*User) bool) SpecificationUser {
+	s := &RequiresSpecification{
+		fields: make(map[string]func(*User) bool, len(fields)),
+		names:  make([]string, 0, len(fields)),
+	}
+	for name, fn := range fields {
+		s.fields[name] = fn
+		s.names = append(s.names, name)
+	}
+	sort.Strings(s.names)
+	return s
+}
+
+func (s *RequiresSpecification) IsSatisfiedBy(u *User) bool {
+	for _, name := range s.names {
+		if !s.fields[name](u) {
+			return false
+		}
+	}
+	return true
+}
+
+func (s *RequiresSpecification) String() string {
+	return "Requires(" + strings.Join(s.names, ", ") + ")"
+}
+
+// Explain reports the failing fields sorted by name
+func (s *RequiresSpecification) Explain(u *User) []string {
+	return reasonMessages(s.reasons(u))
+}
+
+func (s *RequiresSpecification) reasons(u *User) []reason {
+	var reasons []reason
+	for _, name := range s.names {
+		if !s.fields[name](u) {
+			reasons = append(reasons, newReason(CodeFieldInvalid, name))
+		}
+	}
+	return reasons
+}
+
+// RequiresTogether: both predicates hold or neither does (XNOR), e.g. an email
+// requires the verified flag and the flag requires an email
+type RequiresTogetherSpecification struct {
+	a, b func(*User) bool
+}
+
+func RequiresTogether(a, b func(*User) bool) *RequiresTogetherSpecification {
+	return &RequiresTogetherSpecification{
+		a: a,
+		b: b,
+	}
+}
+
+func (s *RequiresTogetherSpecification) IsSatisfiedBy(u *User) bool {
+	return s.a(u) == s.b(u)
+}
+
+func (s *RequiresTogetherSpecification) String() string {
+	return "RequiresTogether"
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRequiresExplain(t *testing.T) {
+	s := Requires(map[string]func(*User) bool{
+		"phone": func(u *User) bool { return u.Phone != "" },
+		"email": func(u *User) bool { return u.Email != "" },
+		"name":  func(u *User) bool { return u.Name != "" },
+	})
+	tests := []struct {
+		name    string
+		user    *User
+		reasons []string
+	}{
+		{"all valid", &User{Name: "boo", Email: "boo@x.com", Phone: "+1"}, nil},
+		{"one invalid", &User{Name: "boo", Phone: "+1"}, []string{"field email: invalid value"}},
+		{"two invalid sorted", &User{Name: "boo"}, []string{"field email: invalid value", "field phone: invalid value"}},
+		{"all invalid", &User{}, []string{"field email: invalid value", "field name: invalid value", "field phone: invalid value"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Explain(s, tt.user); !reflect.DeepEqual(got, tt.reasons) {
+				t.Errorf("Explain = %q, want %q", got, tt.reasons)
+			}
+			if got, want := s.IsSatisfiedBy(tt.user), tt.reasons == nil; got != want {
+				t.Errorf("IsSatisfiedBy = %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestRequiresString(t *testing.T) {
+	s := Requires(map[string]func(*User) bool{
+		"phone": func(u *User) bool { return true },
+		"email": func(u *User) bool { return true },
+	})
+	if got, want := specString(s), "Requires(email, phone)"; got != want {
+		t.Errorf("String = %s, want %s", got, want)
+	}
+}
+
+func TestRequiresTogether(t *testing.T) {
+	s := RequiresTogether(
+		func(u *User) bool { return u.Name != "" },
+		func(u *User) bool { return u.Type == Admin },
+	)
+	RunSpecTests(t, s, []SpecCase{
+		{Name: "both", User: &User{Name: "boo", Type: Admin}, Expected: true},
+		{Name: "neither", User: &User{Type: Personal}, Expected: true},
+		{Name: "only a", User: &User{Name: "boo", Type: Personal}, Expected: false},
+		{Name: "only b", User: &User{Type: Admin}, Expected: false},
+	})
+}
@@ -0,0 +1,103 @@
+package main
+
+import "sync"
+
+// Filter returns the users satisfying the specification
+func Filter(users []*User, spec SpecificationUser) []*User {
+	var result []*User
+	for _, u := range users {
+		if spec.IsSatisfiedBy(u) {
+			result = append(result, u)
+		}
+	}
+	return result
+}
+
+// FilterN returns at most the first n users satisfying the specification,
+// the rest of the users are not evaluated
+func FilterN(users []*User, spec SpecificationUser, n int) []*User {
+	result := []*User{}
+	if n <= 0 {
+		return result
+	}
+	for _, u := range users {
+		if spec.IsSatisfiedBy(u) {
+			result = append(result, u)
+			if len(result) == n {
+				break
+			}
+		}
+	}
+	return result
+}
+
+// FilterUnique returns the users satisfying the specification, evaluating each key once.
+// Only the first user of a key is returned, in the order of the first occurrences.
+func FilterUnique(users []*User, spec SpecificationUser, keyFn func(*User) string) []*User {
+	var result []*User
+	seen := make(map[string]struct{}, len(users))
+	for _, u := range users {
+		key := keyFn(u)
+		if _, ok := seen[key]; ok {
+			continue
+		}
+		seen[key] = struct{}{}
+		if spec.IsSatisfiedBy(u) {
+			result = append(result, u)
+		}
+	}
+	return result
+}
+
+// FilterCache memoizes the results of Filter by the Hash of the specification and the
+// version of the user set, so an equal specification built again hits the cache too.
+// A SpecFunc is not cached: the closures of a literal hash alike whatever they capture.
+// The cached slices are shared, do not modify them.
+type FilterCache struct {
+	mu      sync.Mutex
+	version uint64
+	entries map[filterCacheKey][]*User
+}
+
+type filterCacheKey struct {
+	hash    uint64
+	version uint64
+}
+
+func NewFilterCache() *FilterCache {
+	return &FilterCache{
+		entries: make(map[filterCacheKey][]*User),
+	}
+}
+
+// Filter returns the cached result for the specification if the user set has not changed
+// since it was computed
+func (c *FilterCache) Filter(users []*User, spec SpecificationUser) []*User {
+	if _, ok := spec.(SpecFunc); ok {
+		return Filter(users, spec)
+	}
+	h := Hash(spec)
+	c.mu.Lock()
+	key := filterCacheKey{hash: h, version: c.version}
+	result, ok := c.entries[key]
+	c.mu.Unlock()
+	if ok {
+		return result
+	}
+
+	result = Filter(users, spec)
+	c.mu.Lock()
+	if key.version == c.version {
+		c.entries[key] = result
+	}
+	c.mu.Unlock()
+	return result
+}
+
+// Invalidate bumps the version of the user set, call it when the slice changes
+func (c *FilterCache) Invalidate() {
+	c.mu.Lock()
+	c.version++
+	c.entries = make(map[filterCacheKey][]*User)
+	c.mu.Unlock()
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFilterCacheHitAndInvalidate(t *testing.T) {
+	leaf := &countingSpec{ok: true}
+	users := []*User{{Name: "a"}, {Name: "b"}}
+	c := NewFilterCache()
+	c.Filter(users, leaf)
+	if got := c.Filter(users, leaf); len(got) != 2 {
+		t.Fatalf("cached Filter returned %d users, want 2", len(got))
+	}
+	if leaf.calls != 2 {
+		t.Errorf("repeated Filter evaluated %d times, want 2 (one pass)", leaf.calls)
+	}
+	c.Invalidate()
+	c.Filter(users, leaf)
+	if leaf.calls != 4 {
+		t.Errorf("Filter after Invalidate evaluated %d times in total, want 4", leaf.calls)
+	}
+}
+
+func TestFilterCacheKeysBySpecification(t *testing.T) {
+	users := []*User{{Name: "a"}}
+	on := FeatureEnabled("beta", func(string, *User) bool { return true })
+	off := FeatureEnabled("beta", func(string, *User) bool { return false })
+	c := NewFilterCache()
+	if got := c.Filter(users, on); len(got) != 1 {
+		t.Fatalf("Filter(on) returned %d users, want 1", len(got))
+	}
+	if got := c.Filter(users, off); len(got) != 0 {
+		t.Errorf("Filter(off) returned the cached result of an equal String")
+	}
+}
+
+func TestFilterCacheEqualSpecifications(t *testing.T) {
+	users := []*User{{Name: "a"}, {Name: "b"}}
+	first := &countingSpec{ok: true}
+	again := &countingSpec{ok: true}
+	c := NewFilterCache()
+	c.Filter(users, And(first, Not(IsAdmin)))
+	if got := c.Filter(users, And(again, Not(IsAdmin))); len(got) != 2 {
+		t.Fatalf("Filter of an equal specification returned %d users, want 2", len(got))
+	}
+	if again.calls != 0 {
+		t.Errorf("equal specification evaluated %d times, want 0 (cached)", again.calls)
+	}
+	other := &countingSpec{ok: false}
+	if got := c.Filter(users, And(other, Not(IsAdmin))); len(got) != 0 {
+		t.Errorf("Filter of a different specification returned the cached result")
+	}
+}
+
+func TestFilterCacheSpecFunc(t *testing.T) {
+	calls := 0
+	spec := SpecFunc(func(u *User) bool { calls++; return true })
+	c := NewFilterCache()
+	users := []*User{{Name: "a"}}
+	c.Filter(users, spec)
+	c.Filter(users, spec)
+	if calls != 2 {
+		t.Errorf("SpecFunc evaluated %d times, want 2 (not cached)", calls)
+	}
+}
+
+// namedUsers returns a user per name
+func namedUsers(names ...string) []*User {
+	users := make([]*User, len(names))
+	for i, name := range names {
+		users[i] = &User{Name: name}
+	}
+	return users
+}
+
+func TestFilterNStopsEarly(t *testing.T) {
+	users := namedUsers("a", "bb", "c", "dd", "e", "ff", "g")
+	tests := []struct {
+		n         int
+		names     []string
+		evaluated int
+	}{
+		{1, []string{"a"}, 1},
+		{2, []string{"a", "c"}, 3},
+		{3, []string{"a", "c", "e"}, 5},
+		{4, []string{"a", "c", "e", "g"}, 7},
+		{10, []string{"a", "c", "e", "g"}, 7},
+		{0, []string{}, 0},
+		{-1, []string{}, 0},
+	}
+	for _, tt := range tests {
+		evaluated := 0
+		spec := SpecFunc(func(u *User) bool {
+			evaluated++
+			return len(u.Name) == 1
+		})
+		got := FilterN(users, spec, tt.n)
+		names := []string{}
+		for _, u := range got {
+			names = append(names, u.Name)
+		}
+		if !reflect.DeepEqual(names, tt.names) {
+			t.Errorf("FilterN(%d) = %q, want %q", tt.n, names, tt.names)
+		}
+		if evaluated != tt.evaluated {
+			t.Errorf("FilterN(%d) evaluated %d users, want %d", tt.n, evaluated, tt.evaluated)
+		}
+	}
+}
+
+func TestFilterUnique(t *testing.T) {
+	boo := &User{Name: "boo"}
+	users := []*User{boo, {Name: "alexander"}, boo, {Name: "foo"}, {Name: "boo", Type: Admin}, {Name: "bar"}}
+	evaluated := make(map[string]int)
+	spec := SpecFunc(func(u *User) bool {
+		evaluated[u.Name]++
+		return len(u.Name) == 3
+	})
+	got := FilterUnique(users, spec, userName)
+	want := []*User{boo, users[3], users[5]}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("FilterUnique returned %d users, want boo, foo and bar in order", len(got))
+	}
+	for name, n := range evaluated {
+		if n != 1 {
+			t.Errorf("%s evaluated %d times, want 1", name, n)
+		}
+	}
+	if len(evaluated) != 4 {
+		t.Errorf("%d keys evaluated, want 4", len(evaluated))
+	}
+}
@@ -0,0 +1,185 @@
+package main
+
+import (
+	"fmt"
+	"hash/fnv"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// FeatureEnabled: the flag provider enables the flag for the user.
+// A nil provider means the flag is disabled.
+type FeatureSpecification struct {
+	flag     string
+	provider func(string, *User) bool
+}
+
+func FeatureEnabled(flag string, provider func(string, *User) bool) *FeatureSpecification {
+	return &FeatureSpecification{
+		flag:     flag,
+		provider: provider,
+	}
+}
+
+func (s *FeatureSpecification) IsSatisfiedBy(u *User) bool {
+	return s.provider != nil && s.provider(s.flag, u)
+}
+
+func (s *FeatureSpecification) String() string {
+	return fmt.Sprintf("FeatureEnabled(%s)", s.flag)
+}
+
+// Sampled: inner is evaluated for percent% of the users only, the rest get the default
+// (false), see Otherwise. The users are sampled by the FNV-1a hash of their key (the name
+// by default, see By), so a user always falls on the same side.
+type SampledSpecification struct {
+	percent   float64
+	inner     SpecificationUser
+	keyFn     func(*User) string
+	keyFields []string // the fields read by keyFn, nil if unknown
+	otherwise bool
+}
+
+func Sampled(percent float64, inner SpecificationUser) *SampledSpecification {
+	return &SampledSpecification{
+		percent:   percent,
+		inner:     inner,
+		keyFn:     userName,
+		keyFields: []string{"Name"},
+	}
+}
+
+// By sets the key the users are sampled by
+func (s *SampledSpecification) By(keyFn func(*User) string) *SampledSpecification {
+	s.keyFn, s.keyFields = keyFn, nil
+	return s
+}
+
+// Otherwise sets the result for the users outside of the sample
+func (s *SampledSpecification) Otherwise(result bool) *SampledSpecification {
+	s.otherwise = result
+	return s
+}
+
+func (s *SampledSpecification) Inner() SpecificationUser {
+	return s.inner
+}
+
+// InSample reports whether the user is in the sample
+func (s *SampledSpecification) InSample(u *User) bool {
+	h := fnv.New32a()
+	h.Write([]byte(s.keyFn(u)))
+	return float64(h.Sum32()%10000) < s.percent*100
+}
+
+func (s *SampledSpecification) IsSatisfiedBy(u *User) bool {
+	if !s.InSample(u) {
+		return s.otherwise
+	}
+	return s.inner.IsSatisfiedBy(u)
+}
+
+// Fields returns the fields read by the key, every field for a custom key
+func (s *SampledSpecification) Fields() []string {
+	if s.keyFields == nil {
+		return allUserFields()
+	}
+	return s.keyFields
+}
+
+func (s *SampledSpecification) String() string {
+	return fmt.Sprintf("Sampled(%g%%, %s, otherwise %t, by %s)",
+		s.percent, specString(s.inner), s.otherwise, keyName(s.keyFn, s.keyFields))
+}
+
+// InBucket: the user is assigned to one of the buckets of the experiment. The users are
+// spread over 100 buckets (see Of) by the FNV-1a hash of the experiment and their key
+// (the name by default, see By), so the assignment is stable and independent between
+// the experiments.
+type BucketSpecification struct {
+	experiment string
+	buckets    map[int]struct{}
+	n          int
+	keyFn      func(*User) string
+	keyFields  []string // the fields read by keyFn, nil if unknown
+}
+
+func InBucket(experiment string, buckets ...int) *BucketSpecification {
+	s := &BucketSpecification{
+		experiment: experiment,
+		buckets:    make(map[int]struct{}, len(buckets)),
+		n:          100,
+		keyFn:      userName,
+		keyFields:  []string{"Name"},
+	}
+	for _, b := range buckets {
+		s.buckets[b] = struct{}{}
+	}
+	return s
+}
+
+// Of sets the number of buckets, the buckets are numbered from 0 to n-1
+func (s *BucketSpecification) Of(n int) *BucketSpecification {
+	if n < 1 {
+		n = 1
+	}
+	s.n = n
+	return s
+}
+
+// By sets the key the users are assigned by
+func (s *BucketSpecification) By(keyFn func(*User) string) *BucketSpecification {
+	s.keyFn, s.keyFields = keyFn, nil
+	return s
+}
+
+// Fields returns the fields read by the key, every field for a custom key
+func (s *BucketSpecification) Fields() []string {
+	if s.keyFields == nil {
+		return allUserFields()
+	}
+	return s.keyFields
+}
+
+// Bucket returns the bucket of the user
+func (s *BucketSpecification) Bucket(u *User) int {
+	h := fnv.New64a()
+	h.Write([]byte(s.experiment))
+	h.Write([]byte{0})
+	h.Write([]byte(s.keyFn(u)))
+	// the low bits of FNV-1a depend on the low bits of the bytes only, so modulo a power
+	// of two the experiments would share the assignment: mix the high bits into them
+	sum := h.Sum64()
+	sum ^= sum >> 33
+	sum *= 0xff51afd7ed558ccd
+	sum ^= sum >> 33
+	return int(sum % uint64(s.n))
+}
+
+func (s *BucketSpecification) IsSatisfiedBy(u *User) bool {
+	_, ok := s.buckets[s.Bucket(u)]
+	return ok
+}
+
+func (s *BucketSpecification) String() string {
+	buckets := make([]int, 0, len(s.buckets))
+	for b := range s.buckets {
+		buckets = append(buckets, b)
+	}
+	sort.Ints(buckets)
+	return fmt.Sprintf("InBucket(%s, %v of %d, by %s)", s.experiment, buckets, s.n, keyName(s.keyFn, s.keyFields))
+}
+
+// userName is the default key of the users
+func userName(u *User) string {
+	return u.Name
+}
+
+// keyName names a key function for String: the field it reads or the function name
+func keyName(keyFn func(*User) string, fields []string) string {
+	if fields != nil {
+		return strings.Join(fields, ", ")
+	}
+	return funcName(reflect.ValueOf(keyFn))
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestSampledDeterministic(t *testing.T) {
+	s := Sampled(50, IsAdmin)
+	for i := 0; i < 100; i++ {
+		u := &User{Type: Admin, Name: fmt.Sprint("user", i)}
+		if s.IsSatisfiedBy(u) != s.IsSatisfiedBy(&User{Type: Admin, Name: u.Name}) {
+			t.Fatalf("%s got different decisions", u.Name)
+		}
+	}
+}
+
+func TestSampledDistribution(t *testing.T) {
+	tests := []struct {
+		percent float64
+	}{
+		{0}, {10}, {25}, {50}, {100},
+	}
+	const n = 20000
+	for _, tt := range tests {
+		t.Run(fmt.Sprint(tt.percent), func(t *testing.T) {
+			s := Sampled(tt.percent, IsAdmin)
+			in := 0
+			for i := 0; i < n; i++ {
+				if s.IsSatisfiedBy(&User{Type: Admin, Name: fmt.Sprint("user", i)}) {
+					in++
+				}
+			}
+			got := float64(in) * 100 / n
+			if got < tt.percent-1 || got > tt.percent+1 {
+				t.Errorf("sampled %.2f%% of the users, want %g%% ± 1", got, tt.percent)
+			}
+		})
+	}
+}
+
+func TestSampledOtherwise(t *testing.T) {
+	outside := &User{Type: Personal, Name: "x"}
+	s := Sampled(0, IsAdmin).Otherwise(true)
+	if !s.IsSatisfiedBy(outside) {
+		t.Errorf("a user outside of the sample did not get the Otherwise result")
+	}
+	if Sampled(50, IsAdmin).String() == Sampled(50, IsAdmin).Otherwise(true).String() {
+		t.Errorf("String does not tell Otherwise apart")
+	}
+	if Hash(Sampled(50, IsAdmin)) == Hash(Sampled(50, IsAdmin).Otherwise(true)) {
+		t.Errorf("Hash does not tell Otherwise apart")
+	}
+}
+
+func TestSampledDecisionSignatureReadsKey(t *testing.T) {
+	s := Sampled(50, IsAdmin)
+	var in, out *User
+	for i := 0; in == nil || out == nil; i++ {
+		u := &User{Type: Admin, Name: fmt.Sprint("user", i)}
+		if s.InSample(u) {
+			in = u
+		} else {
+			out = u
+		}
+	}
+	if DecisionSignature(s, in) == DecisionSignature(s, out) {
+		t.Errorf("users in and out of the sample share the signature")
+	}
+}
+
+func TestFeatureEnabled(t *testing.T) {
+	// the fake provider enables "beta" for the admins only
+	provider := func(flag string, u *User) bool {
+		return flag == "beta" && (u.Type == Admin || u.Type == SuperAdmin)
+	}
+	RunSpecTests(t, And(IsAdmin, FeatureEnabled("beta", provider)), []SpecCase{
+		{User: &User{Type: Admin, Name: "boo"}, Expected: true},
+		{User: &User{Type: SuperAdmin, Name: "boo"}, Expected: false},
+		{User: &User{Type: Personal, Name: "boo"}, Expected: false},
+	})
+	RunSpecTests(t, FeatureEnabled("beta", provider), []SpecCase{
+		{User: &User{Type: SuperAdmin, Name: "boo"}, Expected: true},
+		{User: &User{Type: Personal, Name: "boo"}, Expected: false},
+	})
+	RunSpecTests(t, FeatureEnabled("gamma", provider), []SpecCase{
+		{Name: "unknown flag", User: &User{Type: Admin}, Expected: false},
+	})
+	RunSpecTests(t, FeatureEnabled("beta", nil), []SpecCase{
+		{Name: "nil provider", User: &User{Type: Admin}, Expected: false},
+	})
+}
+
+func TestInBucketDeterministic(t *testing.T) {
+	s := InBucket("checkout", 0, 1).Of(4)
+	other := InBucket("search").Of(4)
+	differ := false
+	for i := 0; i < 100; i++ {
+		u := &User{Name: fmt.Sprint("user", i)}
+		b := s.Bucket(u)
+		if b != s.Bucket(&User{Name: u.Name}) {
+			t.Fatalf("%s got different buckets", u.Name)
+		}
+		if b < 0 || b >= 4 {
+			t.Fatalf("%s got bucket %d of 4", u.Name, b)
+		}
+		if got, want := s.IsSatisfiedBy(u), b <= 1; got != want {
+			t.Errorf("%s in bucket %d: IsSatisfiedBy = %v, want %v", u.Name, b, got, want)
+		}
+		if other.Bucket(u) != b {
+			differ = true
+		}
+	}
+	if !differ {
+		t.Errorf("the experiments assign every user to the same bucket")
+	}
+}
+
+func TestInBucketDistribution(t *testing.T) {
+	const n, buckets = 20000, 10
+	s := InBucket("checkout").Of(buckets)
+	counts := make([]int, buckets)
+	for i := 0; i < n; i++ {
+		counts[s.Bucket(&User{Name: fmt.Sprint("user", i)})]++
+	}
+	// each bucket within 10% of the expected n/buckets
+	for b, count := range counts {
+		if count < n/buckets*9/10 || count > n/buckets*11/10 {
+			t.Errorf("bucket %d has %d users, want about %d", b, count, n/buckets)
+		}
+	}
+}
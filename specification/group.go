@@ -0,0 +1,75 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// InGroup: the groups resolved for the user contain the group.
+// Group names are compared case-sensitively, a nil resolver is never satisfied.
+type GroupSpecification struct {
+	group    string
+	resolver func(*User) []string
+}
+
+func InGroup(group string, resolver func(*User) []string) *GroupSpecification {
+	return &GroupSpecification{
+		group:    group,
+		resolver: resolver,
+	}
+}
+
+func (s *GroupSpecification) IsSatisfiedBy(u *User) bool {
+	if s.resolver == nil {
+		return false
+	}
+	for _, group := range s.resolver(u) {
+		if group == s.group {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *GroupSpecification) String() string {
+	return fmt.Sprintf("InGroup(%s)", s.group)
+}
+
+// IsOneOf: the user is pointer-identical to one of the users, equal fields are not enough.
+// Nil users of the set never match.
+type IdentitySpecification struct {
+	users map[*User]struct{}
+}
+
+func IsOneOf(users ...*User) *IdentitySpecification {
+	s := &IdentitySpecification{
+		users: make(map[*User]struct{}, len(users)),
+	}
+	for _, u := range users {
+		if u != nil {
+			s.users[u] = struct{}{}
+		}
+	}
+	return s
+}
+
+// IsSameAs: the user is the target itself, a nil target matches nobody
+func IsSameAs(target *User) *IdentitySpecification {
+	return IsOneOf(target)
+}
+
+func (s *IdentitySpecification) IsSatisfiedBy(u *User) bool {
+	_, ok := s.users[u]
+	return ok
+}
+
+// String lists the names of the users, users with the same name are not told apart
+func (s *IdentitySpecification) String() string {
+	names := make([]string, 0, len(s.users))
+	for u := range s.users {
+		names = append(names, u.Name)
+	}
+	sort.Strings(names)
+	return "IsOneOf(" + strings.Join(names, ", ") + ")"
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestInGroup(t *testing.T) {
+	// the fake directory of the groups by user name
+	groups := map[string][]string{
+		"boo":   {"dev", "ops"},
+		"foo":   {"Ops"},
+		"alone": {},
+	}
+	resolver := func(u *User) []string {
+		return groups[u.Name]
+	}
+	RunSpecTests(t, InGroup("ops", resolver), []SpecCase{
+		{Name: "member of several groups", User: &User{Name: "boo"}, Expected: true},
+		{Name: "group of another case", User: &User{Name: "foo"}, Expected: false},
+		{Name: "no groups", User: &User{Name: "alone"}, Expected: false},
+		{Name: "unknown user", User: &User{Name: "nobody"}, Expected: false},
+	})
+	RunSpecTests(t, InGroup("ops", nil), []SpecCase{
+		{Name: "nil resolver", User: &User{Name: "boo"}, Expected: false},
+	})
+
+	// the resolver is called on every evaluation
+	u := &User{Name: "alone"}
+	s := InGroup("ops", resolver)
+	if s.IsSatisfiedBy(u) {
+		t.Fatalf("user without groups is in ops")
+	}
+	groups["alone"] = []string{"ops"}
+	if !s.IsSatisfiedBy(u) {
+		t.Errorf("group added by the resolver is not seen")
+	}
+}
+
+func TestIsOneOf(t *testing.T) {
+	boo, foo := &User{Name: "boo"}, &User{Name: "foo"}
+	twin := *boo
+	RunSpecTests(t, IsOneOf(boo, foo, nil), []SpecCase{
+		{Name: "member", User: boo, Expected: true},
+		{Name: "other member", User: foo, Expected: true},
+		{Name: "copy with identical fields", User: &twin, Expected: false},
+		{Name: "nil user", User: nil, Expected: false},
+	})
+	RunSpecTests(t, IsSameAs(nil), []SpecCase{
+		{Name: "nil target", User: boo, Expected: false},
+	})
+	if got, want := IsOneOf(foo, boo).String(), "IsOneOf(boo, foo)"; got != want {
+		t.Errorf("String = %s, want %s", got, want)
+	}
+}
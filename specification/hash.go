@@ -0,0 +1,149 @@
+package main
+
+import (
+	"encoding/binary"
+	"fmt"
+	"hash/fnv"
+	"reflect"
+	"runtime"
+	"sort"
+	"strings"
+)
+
+// Hash returns a stable key of the specification tree computed with FNV-1a.
+// The children of And and Or are hashed regardless of their order, the children of
+// the other composites and wrappers in order. Every node is also hashed by its type
+// and parameters (see nodeKey), not by its String, which may leave parameters out.
+// Equal trees hash equal; trees that are only logically equivalent may not.
+// The specifications of users by identity (IsOneOf, NameUniqueNormalized) hash by
+// the addresses of the users, so their hashes are stable within a process only, and
+// closures of the same function literal are alike whatever they capture.
+func Hash(spec SpecificationUser) uint64 {
+	switch s := spec.(type) {
+	case *AndSpecification:
+		return hashNode("And", s.specs, false)
+	case *OrSpecification:
+		return hashNode("Or", s.specs, false)
+	case Composite:
+		return hashNode(nodeKey(spec), s.Children(), true)
+	case Wrapper:
+		return hashNode(nodeKey(spec), []SpecificationUser{s.Inner()}, true)
+	}
+	return hashNode("leaf:"+nodeKey(spec), nil, true)
+}
+
+// hashKeyer is implemented by the specifications whose parameters cannot be read by
+// reflection (times, locations, regular expressions), hashKey describes them all
+type hashKeyer interface {
+	hashKey() string
+}
+
+// nodeKey describes a node by its type and its parameters, the children excluded
+func nodeKey(spec SpecificationUser) string {
+	if k, ok := spec.(hashKeyer); ok {
+		return reflect.TypeOf(spec).String() + k.hashKey()
+	}
+	return leafKey(spec)
+}
+
+// leafKey describes a node by its type and the values of its fields: the basic kinds,
+// structs, slices, arrays and maps (by sorted keys) are written out, functions by their
+// name, so closures of the same literal are alike, pointers by their address and
+// channels by their capacity. The specifications held in interfaces are children and
+// are left out, as are the sync types and the fields tagged `hash:"-"` (internal state).
+func leafKey(spec SpecificationUser) string {
+	var sb strings.Builder
+	v := reflect.ValueOf(spec)
+	sb.WriteString(v.Type().String())
+	for v.Kind() == reflect.Ptr && !v.IsNil() {
+		v = v.Elem()
+	}
+	writeLeafValue(&sb, v)
+	return sb.String()
+}
+
+var specificationType = reflect.TypeOf((*SpecificationUser)(nil)).Elem()
+
+func writeLeafValue(sb *strings.Builder, v reflect.Value) {
+	switch v.Kind() {
+	case reflect.Bool, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64, reflect.Complex64, reflect.Complex128, reflect.String:
+		fmt.Fprintf(sb, "%q", fmt.Sprint(v))
+	case reflect.Func:
+		sb.WriteString("func " + funcName(v))
+	case reflect.Ptr:
+		fmt.Fprintf(sb, "%#x", v.Pointer())
+	case reflect.Chan:
+		fmt.Fprintf(sb, "chan %d", v.Cap())
+	case reflect.Interface:
+		if v.IsNil() {
+			sb.WriteString("nil")
+			return
+		}
+		if v.Elem().Type().Implements(specificationType) {
+			return
+		}
+		sb.WriteString(v.Elem().Type().String())
+		writeLeafValue(sb, v.Elem())
+	case reflect.Struct:
+		if v.Type().PkgPath() == "sync" {
+			return
+		}
+		sb.WriteString("{")
+		for i := 0; i < v.NumField(); i++ {
+			if v.Type().Field(i).Tag.Get("hash") == "-" {
+				continue
+			}
+			sb.WriteString(v.Type().Field(i).Name + ":")
+			writeLeafValue(sb, v.Field(i))
+			sb.WriteString(" ")
+		}
+		sb.WriteString("}")
+	case reflect.Slice, reflect.Array:
+		sb.WriteString("[")
+		for i := 0; i < v.Len(); i++ {
+			writeLeafValue(sb, v.Index(i))
+			sb.WriteString(" ")
+		}
+		sb.WriteString("]")
+	case reflect.Map:
+		entries := make([]string, 0, v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			var entry strings.Builder
+			writeLeafValue(&entry, iter.Key())
+			entry.WriteString(":")
+			writeLeafValue(&entry, iter.Value())
+			entries = append(entries, entry.String())
+		}
+		sort.Strings(entries)
+		sb.WriteString("map[" + strings.Join(entries, " ") + "]")
+	}
+}
+
+func hashNode(tag string, children []SpecificationUser, ordered bool) uint64 {
+	hashes := make([]uint64, len(children))
+	for i, child := range children {
+		hashes[i] = Hash(child)
+	}
+	if !ordered {
+		sort.Slice(hashes, func(i, j int) bool { return hashes[i] < hashes[j] })
+	}
+	h := fnv.New64a()
+	h.Write([]byte(tag))
+	var buf [8]byte
+	for _, v := range hashes {
+		binary.LittleEndian.PutUint64(buf[:], v)
+		h.Write(buf[:])
+	}
+	return h.Sum64()
+}
+
+// funcName returns the name of the function, the same for the closures of a literal
+func funcName(fn reflect.Value) string {
+	if fn.IsNil() {
+		return "nil"
+	}
+	return runtime.FuncForPC(fn.Pointer()).Name()
+}
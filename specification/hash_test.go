@@ -0,0 +1,119 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestHashStableForEqualTrees(t *testing.T) {
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	specs := []func() SpecificationUser{
+		func() SpecificationUser { return UpdatedWithin(time.Hour) },
+		func() SpecificationUser { return AccountOlderThan(24 * time.Hour) },
+		func() SpecificationUser { return SameDayAs(start) },
+		func() SpecificationUser { return NameChangedWithin(time.Hour) },
+		func() SpecificationUser { return OnWeekdays().In(time.UTC) },
+		func() SpecificationUser { return InGroup("ops", nil) },
+		func() SpecificationUser { return SatisfiesPolicy(UsernamePolicy{MinLength: 3}) },
+		func() SpecificationUser { return ActiveBetween(start, start.Add(time.Hour), IsAdmin) },
+		func() SpecificationUser { return And(IsAdmin, Not(Locked)) },
+	}
+	for _, build := range specs {
+		a, b := build(), build()
+		if Hash(a) != Hash(b) {
+			t.Errorf("Hash(%s) differs between equal trees", specString(a))
+		}
+	}
+}
+
+func TestHashParameters(t *testing.T) {
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name string
+		a, b SpecificationUser
+	}{
+		{"UpdatedWithin", UpdatedWithin(time.Hour), UpdatedWithin(time.Minute)},
+		{"ActiveBetween window", ActiveBetween(start, start.Add(time.Hour), IsAdmin), ActiveBetween(start, start.Add(2*time.Hour), IsAdmin)},
+		{"ActiveBetween otherwise", ActiveBetween(start, start.Add(time.Hour), IsAdmin), ActiveBetween(start, start.Add(time.Hour), IsAdmin).Otherwise(true)},
+		{"InGroup", InGroup("ops", nil), InGroup("dev", nil)},
+		{"InGroup resolver", InGroup("ops", opsGroups), InGroup("ops", devGroups)},
+		{"FeatureEnabled provider", FeatureEnabled("beta", enableAll), FeatureEnabled("beta", enableNone)},
+		{"NameNotReserved", NameNotReserved(opsGroups), NameNotReserved(devGroups)},
+		{"RequiresTogether", RequiresTogether(hasName, isLocked), RequiresTogether(hasName, hasName)},
+		{"NameUniqueNormalized names", NameUniqueNormalized([]*User{{Name: "boo"}}), NameUniqueNormalized([]*User{{Name: "foo"}})},
+		{"IsOneOf users with equal names", IsOneOf(&User{Name: "boo"}), IsOneOf(&User{Name: "boo"})},
+		{"Percentile threshold", Percentile([]*User{{Name: "a"}}, nameLength, 50), Percentile([]*User{{Name: "abc"}}, nameLength, 50)},
+		{"SameDayAs zone", SameDayAs(start), SameDayAs(start).In(time.FixedZone("JST", 9*60*60))},
+		{"OnWeekdays days", OnWeekdays().In(time.UTC), OnWeekdays(time.Saturday).In(time.UTC)},
+		{"SatisfiesPolicy reserved", SatisfiesPolicy(UsernamePolicy{Reserved: []string{"a, b"}}), SatisfiesPolicy(UsernamePolicy{Reserved: []string{"a", "b"}})},
+		{"Majority child", Majority(IsAdmin, Locked), Majority(IsAdmin, NotLocked)},
+		{"SatisfiedInRange bounds", SatisfiedInRange(1, 2, IsAdmin), SatisfiedInRange(1, 3, IsAdmin)},
+	}
+	for _, tt := range tests {
+		if Hash(tt.a) == Hash(tt.b) {
+			t.Errorf("%s: different parameters hash equal", tt.name)
+		}
+	}
+}
+
+func opsGroups(*User) []string      { return []string{"ops"} }
+func devGroups(*User) []string      { return []string{"dev"} }
+func enableAll(string, *User) bool  { return true }
+func enableNone(string, *User) bool { return false }
+func hasName(u *User) bool          { return u.Name != "" }
+func isLocked(u *User) bool         { return u.Locked }
+func nameLength(u *User) float64    { return float64(len(u.Name)) }
+
+type plainLeaf struct {
+	n int
+}
+
+func (s *plainLeaf) IsSatisfiedBy(u *User) bool {
+	return u.Type == UserType(s.n)
+}
+
+func TestHashLeafWithoutString(t *testing.T) {
+	if Hash(&plainLeaf{n: 1}) != Hash(&plainLeaf{n: 1}) {
+		t.Errorf("equal leaves without String hash differently")
+	}
+	if Hash(&plainLeaf{n: 1}) == Hash(&plainLeaf{n: 2}) {
+		t.Errorf("leaves with different fields hash equal")
+	}
+}
+
+func TestHashUnorderedAndOr(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b SpecificationUser
+		eq   bool
+	}{
+		{"And reordered", And(IsAdmin, Locked), And(Locked, IsAdmin), true},
+		{"Or reordered", Or(IsAdmin, Locked), Or(Locked, IsAdmin), true},
+		{"And vs Or", And(IsAdmin, Locked), Or(IsAdmin, Locked), false},
+		{"Not", Not(IsAdmin), IsAdmin, false},
+	}
+	for _, tt := range tests {
+		if got := Hash(tt.a) == Hash(tt.b); got != tt.eq {
+			t.Errorf("%s: equal hashes = %v, want %v", tt.name, got, tt.eq)
+		}
+	}
+}
+
+// TestHashGolden pins the hashes, they must not change between runs or releases,
+// also for the leaves without String
+func TestHashGolden(t *testing.T) {
+	tests := []struct {
+		spec SpecificationUser
+		want uint64
+	}{
+		{And(IsAdmin, Not(Locked)), 0x9c2dcec5ed9ec46a},
+		{UpdatedWithin(time.Hour), 0xccf0aa5c5de96bef},
+		{Majority(&plainLeaf{n: 1}, IsAdmin), 0x2ef35219646e55e2},
+		{SameDayAs(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)), 0xaaaa3631e79b19f0},
+	}
+	for _, tt := range tests {
+		if got := Hash(tt.spec); got != tt.want {
+			t.Errorf("Hash(%s) = %#x, want %#x", specString(tt.spec), got, tt.want)
+		}
+	}
+}
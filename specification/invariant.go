@@ -0,0 +1,53 @@
+package main
+
+import "sync"
+
+// InvariantResult is the outcome of a registered invariant
+type InvariantResult struct {
+	Name    string
+	Ok      bool
+	Message string
+}
+
+type invariant struct {
+	name  string
+	check func([]*User) (bool, string)
+}
+
+var (
+	invariantsMu sync.RWMutex
+	invariants   []invariant
+)
+
+// Invariant registers a system-wide check over all the users, e.g.
+//
+//	Invariant("super admin exists", func(users []*User) (bool, string) {
+//		return ExistsMatching(IsSuperAdmin).IsSatisfiedBySet(users), "no super admin"
+//	})
+//
+// An invariant registered again under the same name replaces the previous check.
+func Invariant(name string, check func([]*User) (bool, string)) {
+	invariantsMu.Lock()
+	defer invariantsMu.Unlock()
+	for i := range invariants {
+		if invariants[i].name == name {
+			invariants[i].check = check
+			return
+		}
+	}
+	invariants = append(invariants, invariant{name: name, check: check})
+}
+
+// EvaluateInvariants runs all the registered invariants over the users
+// in the order of registration
+func EvaluateInvariants(users []*User) []InvariantResult {
+	invariantsMu.RLock()
+	list := append([]invariant(nil), invariants...)
+	invariantsMu.RUnlock()
+	results := make([]InvariantResult, 0, len(list))
+	for _, inv := range list {
+		ok, msg := inv.check(users)
+		results = append(results, InvariantResult{Name: inv.name, Ok: ok, Message: msg})
+	}
+	return results
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+// restoreInvariants returns the function restoring the registered invariants
+func restoreInvariants() func() {
+	invariantsMu.Lock()
+	saved := append([]invariant(nil), invariants...)
+	invariantsMu.Unlock()
+	return func() {
+		invariantsMu.Lock()
+		invariants = saved
+		invariantsMu.Unlock()
+	}
+}
+
+func TestEvaluateInvariants(t *testing.T) {
+	defer restoreInvariants()()
+	Invariant("super admin exists", func(users []*User) (bool, string) {
+		return ExistsMatching(IsSuperAdmin).IsSatisfiedBySet(users), "no super admin"
+	})
+	Invariant("at most one locked", func(users []*User) (bool, string) {
+		return AtMostN(1, Locked).IsSatisfiedBySet(users), "too many locked users"
+	})
+
+	tests := []struct {
+		name  string
+		users []*User
+		want  []InvariantResult
+	}{
+		{"both hold", []*User{{Type: SuperAdmin}, {Locked: true}}, []InvariantResult{
+			{Name: "super admin exists", Ok: true, Message: "no super admin"},
+			{Name: "at most one locked", Ok: true, Message: "too many locked users"},
+		}},
+		{"both fail", []*User{{Type: Admin, Locked: true}, {Locked: true}}, []InvariantResult{
+			{Name: "super admin exists", Ok: false, Message: "no super admin"},
+			{Name: "at most one locked", Ok: false, Message: "too many locked users"},
+		}},
+		{"one fails", []*User{{Type: SuperAdmin, Locked: true}, {Locked: true}}, []InvariantResult{
+			{Name: "super admin exists", Ok: true, Message: "no super admin"},
+			{Name: "at most one locked", Ok: false, Message: "too many locked users"},
+		}},
+	}
+	for _, tt := range tests {
+		if got := EvaluateInvariants(tt.users); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: EvaluateInvariants = %+v, want %+v", tt.name, got, tt.want)
+		}
+	}
+
+	// registering a name again replaces the check in its place
+	Invariant("super admin exists", func([]*User) (bool, string) { return true, "" })
+	got := EvaluateInvariants(nil)
+	if len(got) != 2 || got[0].Name != "super admin exists" || !got[0].Ok {
+		t.Errorf("EvaluateInvariants after the replacement = %+v", got)
+	}
+}
@@ -0,0 +1,184 @@
+package main
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// ldapAttrs builds the leaf of an equality filter by its attribute,
+// the attributes are case-insensitive
+var ldapAttrs = map[string]func(value string) (SpecificationUser, error){
+	"type": func(value string) (SpecificationUser, error) {
+		typ, err := ParseUserType(value)
+		if err != nil {
+			return nil, err
+		}
+		return &TypeSpecification{typ: typ}, nil
+	},
+	"name": func(value string) (SpecificationUser, error) {
+		return Name(value), nil
+	},
+	"locked": func(value string) (SpecificationUser, error) {
+		switch strings.ToUpper(value) {
+		case "TRUE":
+			return Locked, nil
+		case "FALSE":
+			return NotLocked, nil
+		}
+		return nil, fmt.Errorf("invalid boolean %q", value)
+	},
+	"country": func(value string) (SpecificationUser, error) {
+		return CountryIn(value)
+	},
+}
+
+// ParseLDAPFilter builds a specification from an RFC 4515 filter like
+//
+//	(&(type=ADMIN)(!(locked=TRUE)))
+//
+// Only the equality match of the attributes type, name, locked (TRUE or FALSE) and
+// country is supported, combined with &, | and !. The values may contain the \XX
+// escapes of the RFC; substrings (*), presence and the other match types are errors.
+func ParseLDAPFilter(filter string) (SpecificationUser, error) {
+	p := &ldapParser{s: filter}
+	spec, err := p.filter()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.s) {
+		return nil, fmt.Errorf("ldap: unexpected %q at %d", p.s[p.pos], p.pos)
+	}
+	return spec, nil
+}
+
+type ldapParser struct {
+	s   string
+	pos int
+}
+
+func (p *ldapParser) expect(c byte) error {
+	if p.pos >= len(p.s) {
+		return fmt.Errorf("ldap: expected %q at %d, got end of filter", c, p.pos)
+	}
+	if p.s[p.pos] != c {
+		return fmt.Errorf("ldap: expected %q at %d, got %q", c, p.pos, p.s[p.pos])
+	}
+	p.pos++
+	return nil
+}
+
+func (p *ldapParser) filter() (SpecificationUser, error) {
+	if err := p.expect('('); err != nil {
+		return nil, err
+	}
+	if p.pos >= len(p.s) {
+		return nil, fmt.Errorf("ldap: unexpected end of filter at %d", p.pos)
+	}
+	var spec SpecificationUser
+	var err error
+	switch p.s[p.pos] {
+	case '&':
+		p.pos++
+		var specs []SpecificationUser
+		if specs, err = p.list('&'); err == nil {
+			spec = And(specs...)
+		}
+	case '|':
+		p.pos++
+		var specs []SpecificationUser
+		if specs, err = p.list('|'); err == nil {
+			spec = Or(specs...)
+		}
+	case '!':
+		p.pos++
+		var inner SpecificationUser
+		if inner, err = p.filter(); err == nil {
+			spec = Not(inner)
+		}
+	default:
+		spec, err = p.item()
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := p.expect(')'); err != nil {
+		return nil, err
+	}
+	return spec, nil
+}
+
+// list parses the filters of & and |, at least one is required
+func (p *ldapParser) list(op byte) ([]SpecificationUser, error) {
+	var specs []SpecificationUser
+	for p.pos < len(p.s) && p.s[p.pos] == '(' {
+		spec, err := p.filter()
+		if err != nil {
+			return nil, err
+		}
+		specs = append(specs, spec)
+	}
+	if len(specs) == 0 {
+		return nil, fmt.Errorf("ldap: %q without filters at %d", op, p.pos)
+	}
+	return specs, nil
+}
+
+func (p *ldapParser) item() (SpecificationUser, error) {
+	start := p.pos
+	eq := strings.IndexByte(p.s[start:], '=')
+	if eq < 0 {
+		return nil, fmt.Errorf("ldap: expected attribute=value at %d", start)
+	}
+	attr := p.s[start : start+eq]
+	if strings.ContainsAny(attr, "()") {
+		return nil, fmt.Errorf("ldap: expected attribute=value at %d", start)
+	}
+	if n := len(attr); n > 0 && strings.IndexByte("~<>:", attr[n-1]) >= 0 {
+		return nil, fmt.Errorf("ldap: unsupported match type %q at %d", attr[n-1:]+"=", start+n-1)
+	}
+	build, ok := ldapAttrs[strings.ToLower(attr)]
+	if !ok {
+		return nil, fmt.Errorf("ldap: unknown attribute %q at %d", attr, start)
+	}
+	p.pos = start + eq + 1
+	valuePos := p.pos
+	value, err := p.value()
+	if err != nil {
+		return nil, err
+	}
+	spec, err := build(value)
+	if err != nil {
+		return nil, fmt.Errorf("ldap: %s at %d: %w", attr, valuePos, err)
+	}
+	return spec, nil
+}
+
+// value reads the assertion value up to the closing parenthesis, decoding the escapes
+func (p *ldapParser) value() (string, error) {
+	var sb strings.Builder
+	for p.pos < len(p.s) {
+		switch c := p.s[p.pos]; c {
+		case ')':
+			return sb.String(), nil
+		case '(':
+			return "", fmt.Errorf("ldap: unescaped '(' in value at %d", p.pos)
+		case '*':
+			return "", fmt.Errorf("ldap: substring and presence filters are not supported at %d", p.pos)
+		case '\\':
+			if p.pos+3 > len(p.s) {
+				return "", fmt.Errorf("ldap: truncated escape at %d", p.pos)
+			}
+			b, err := hex.DecodeString(p.s[p.pos+1 : p.pos+3])
+			if err != nil {
+				return "", fmt.Errorf("ldap: invalid escape %q at %d", p.s[p.pos:p.pos+3], p.pos)
+			}
+			sb.Write(b)
+			p.pos += 3
+		default:
+			sb.WriteByte(c)
+			p.pos++
+		}
+	}
+	return "", fmt.Errorf("ldap: unexpected end of filter at %d", p.pos)
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestParseLDAPFilter(t *testing.T) {
+	tests := []struct {
+		filter string
+		want   string
+	}{
+		{"(&(type=ADMIN)(!(locked=TRUE)))", "And(Type(ADMIN), Not(Locked))"},
+		{"(type=admin)", "Type(ADMIN)"},
+		{"(NAME=Boo)", "Name(boo)"},
+		{"(locked=false)", "Not(Locked)"},
+		{"(country=DE)", "CountryIn(DE)"},
+		{"(|(name=boo)(name=foo)(locked=false))", "Or(Name(boo), Name(foo), Not(Locked))"},
+		{"(&(name=boo))", "And(Name(boo))"},
+		{"(name=SUPER ADMIN)", "Name(super admin)"},
+		{`(name=a\29b\2a)`, "Name(a)b*)"},
+		{"(&(|(type=ADMIN)(type=SUPER ADMIN))(!(|(locked=TRUE)(name=root))))",
+			"And(Or(Type(ADMIN), Type(SUPER ADMIN)), Not(Or(Locked, Name(root))))"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.filter, func(t *testing.T) {
+			spec, err := ParseLDAPFilter(tt.filter)
+			if err != nil {
+				t.Fatalf("ParseLDAPFilter: %v", err)
+			}
+			if got := specString(spec); got != tt.want {
+				t.Errorf("ParseLDAPFilter = %s, want %s", got, tt.want)
+			}
+		})
+	}
+
+	spec, err := ParseLDAPFilter("(&(|(type=ADMIN)(type=SUPER ADMIN))(!(|(locked=TRUE)(name=root))))")
+	if err != nil {
+		t.Fatalf("ParseLDAPFilter: %v", err)
+	}
+	RunSpecTests(t, spec, []SpecCase{
+		{User: &User{Type: Admin, Name: "boo"}, Expected: true},
+		{User: &User{Type: SuperAdmin, Name: "boo"}, Expected: true},
+		{User: &User{Type: Personal, Name: "boo"}, Expected: false},
+		{User: &User{Type: Admin, Name: "boo", Locked: true}, Expected: false},
+		{User: &User{Type: Admin, Name: "Root"}, Expected: false},
+	})
+}
+
+func TestParseLDAPFilterErrors(t *testing.T) {
+	tests := []struct {
+		filter string
+		err    string
+	}{
+		{"", "ldap: expected '(' at 0, got end of filter"},
+		{"type=ADMIN", "ldap: expected '(' at 0, got 't'"},
+		{"(", "ldap: unexpected end of filter at 1"},
+		{"(type=ADMIN", "ldap: unexpected end of filter at 11"},
+		{"(&(type=ADMIN)(!(locked=TRUE))", "ldap: expected ')' at 30, got end of filter"},
+		{"(type=ADMIN))", "ldap: unexpected ')' at 12"},
+		{"(&(name=a)x)", "ldap: expected ')' at 10, got 'x'"},
+		{"(foo=bar)", `ldap: unknown attribute "foo" at 1`},
+		{"(=boo)", `ldap: unknown attribute "" at 1`},
+		{"(nameboo)", "ldap: expected attribute=value at 1"},
+		{"(&)", "ldap: '&' without filters at 2"},
+		{"(|)", "ldap: '|' without filters at 2"},
+		{"(!)", "ldap: expected '(' at 2, got ')'"},
+		{"(!(name=a)(name=b))", "ldap: expected ')' at 10, got '('"},
+		{"(type=ROOT)", `ldap: type at 6: unknown user type "ROOT"`},
+		{"(locked=yes)", `ldap: locked at 8: invalid boolean "yes"`},
+		{"(name=bo*)", "ldap: substring and presence filters are not supported at 8"},
+		{"(name=*)", "ldap: substring and presence filters are not supported at 6"},
+		{"(name~=boo)", `ldap: unsupported match type "~=" at 5`},
+		{"(name>=a)", `ldap: unsupported match type ">=" at 5`},
+		{"(name:=a)", `ldap: unsupported match type ":=" at 5`},
+		{"(name=a(b)", "ldap: unescaped '(' in value at 7"},
+		{`(name=a\zz)`, `ldap: invalid escape "\\zz" at 7`},
+		{`(name=a\2`, "ldap: truncated escape at 7"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.filter, func(t *testing.T) {
+			spec, err := ParseLDAPFilter(tt.filter)
+			if err == nil || err.Error() != tt.err {
+				t.Errorf("ParseLDAPFilter error = %v, want %s", err, tt.err)
+			}
+			if spec != nil {
+				t.Errorf("ParseLDAPFilter returned %s with the error", specString(spec))
+			}
+		})
+	}
+}
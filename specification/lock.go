@@ -0,0 +1,68 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// LockedBecause: the user is locked with the reason, compared case-insensitively
+type LockReasonSpecification struct {
+	reason string
+}
+
+func LockedBecause(reason string) *LockReasonSpecification {
+	return &LockReasonSpecification{
+		reason: reason,
+	}
+}
+
+func (s *LockReasonSpecification) IsSatisfiedBy(u *User) bool {
+	return u.Locked && strings.EqualFold(u.LockReason, s.reason)
+}
+
+func (s *LockReasonSpecification) String() string {
+	return fmt.Sprintf("LockedBecause(%s)", s.reason)
+}
+
+// LockedLongerThan: the user has been locked for more than d
+type LockedDurationSpecification struct {
+	d   time.Duration
+	now func() time.Time
+}
+
+func LockedLongerThan(d time.Duration) *LockedDurationSpecification {
+	return &LockedDurationSpecification{
+		d:   d,
+		now: time.Now,
+	}
+}
+
+// WithClock replaces the source of the current time
+func (s *LockedDurationSpecification) WithClock(now func() time.Time) *LockedDurationSpecification {
+	s.now = now
+	return s
+}
+
+func (s *LockedDurationSpecification) IsSatisfiedBy(u *User) bool {
+	return u.Locked && !u.LockedAt.IsZero() && s.now().Sub(u.LockedAt) > s.d
+}
+
+func (s *LockedDurationSpecification) String() string {
+	return fmt.Sprintf("LockedLongerThan(%v)", s.d)
+}
+
+// ConsistentLockState: a locked user has a lock reason and an unlocked user has none
+type ConsistentLockSpecification struct{}
+
+func ConsistentLockState() *ConsistentLockSpecification {
+	return &ConsistentLockSpecification{}
+}
+
+func (s *ConsistentLockSpecification) IsSatisfiedBy(u *User) bool {
+	return u.Locked == (u.LockReason != "")
+}
+
+func (s *ConsistentLockSpecification) String() string {
+	return "ConsistentLockState"
+}
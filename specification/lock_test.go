@@ -0,0 +1,37 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestEligibleForAutoUnlock(t *testing.T) {
+	now := time.Now()
+	RunSpecTests(t, EligibleForAutoUnlock, []SpecCase{
+		{Name: "long inactive", User: &User{Locked: true, LockReason: "inactive", LockedAt: now.AddDate(0, 0, -120)}, Expected: true},
+		{Name: "reason in another case", User: &User{Locked: true, LockReason: "Inactive", LockedAt: now.AddDate(0, 0, -120)}, Expected: true},
+		{Name: "locked for fraud", User: &User{Locked: true, LockReason: "fraud", LockedAt: now.AddDate(0, 0, -120)}, Expected: false},
+		{Name: "recently inactive", User: &User{Locked: true, LockReason: "inactive", LockedAt: now.AddDate(0, 0, -30)}, Expected: false},
+		{Name: "lock time unknown", User: &User{Locked: true, LockReason: "inactive"}, Expected: false},
+		{Name: "unlocked", User: &User{LockReason: "inactive", LockedAt: now.AddDate(0, 0, -120)}, Expected: false},
+	})
+}
+
+func TestLockedLongerThan(t *testing.T) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	s := LockedLongerThan(time.Hour).WithClock(func() time.Time { return now })
+	RunSpecTests(t, s, []SpecCase{
+		{Name: "over the duration", User: &User{Locked: true, LockedAt: now.Add(-time.Hour - time.Second)}, Expected: true},
+		{Name: "exactly the duration", User: &User{Locked: true, LockedAt: now.Add(-time.Hour)}, Expected: false},
+		{Name: "under the duration", User: &User{Locked: true, LockedAt: now.Add(-time.Minute)}, Expected: false},
+	})
+}
+
+func TestConsistentLockState(t *testing.T) {
+	RunSpecTests(t, ConsistentLockState(), []SpecCase{
+		{Name: "locked with a reason", User: &User{Locked: true, LockReason: "fraud"}, Expected: true},
+		{Name: "locked without a reason", User: &User{Locked: true}, Expected: false},
+		{Name: "unlocked with a reason", User: &User{LockReason: "fraud"}, Expected: false},
+		{Name: "unlocked without a reason", User: &User{}, Expected: true},
+	})
+}
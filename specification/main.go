@@ -6,6 +6,7 @@ package main
 import (
 	"fmt"
 	"strings"
+	"time"
 )
 
 type UserType int
@@ -17,18 +18,38 @@ const (
 )
 
 type User struct {
-	Type   UserType
-	Name   string
-	Locked bool
+	Type       UserType
+	Name       string
+	Locked     bool
+	LockReason string
+	LockedAt   time.Time
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+	Metadata   map[string]interface{}
+	Phone      string
+	Email      string
+	Country    string
+	// DeleteAfter is the time the account may be deleted at, zero if not scheduled
+	DeleteAfter time.Time
+	// NameChangedAt is the time of the last rename, zero if never renamed
+	NameChangedAt time.Time
 }
 
-func (u User) String() string {
-	names := map[UserType]string{
-		Personal:   "PERSONAL",
-		Admin:      "ADMIN",
-		SuperAdmin: "SUPER ADMIN",
+var userTypeNames = map[UserType]string{
+	Personal:   "PERSONAL",
+	Admin:      "ADMIN",
+	SuperAdmin: "SUPER ADMIN",
+}
+
+func (t UserType) String() string {
+	if name, ok := userTypeNames[t]; ok {
+		return name
 	}
-	return fmt.Sprintf("%s (Type:%v Locked:%t)", u.Name, names[u.Type], u.Locked)
+	return fmt.Sprintf("UserType(%d)", int(t))
+}
+
+func (u User) String() string {
+	return fmt.Sprintf("%s (Type:%v Locked:%t)", u.Name, u.Type, u.Locked)
 }
 
 type SpecificationUser interface {
@@ -38,6 +59,13 @@ type SpecificationUser interface {
 	// to use a template in interface{} type, also redefine specifications And, Or, Not, etc.
 }
 
+// SpecFunc is an adapter to use an ordinary function as a specification
+type SpecFunc func(u *User) bool
+
+func (f SpecFunc) IsSatisfiedBy(u *User) bool {
+	return f(u)
+}
+
 //And
 type AndSpecification struct {
 	specs []SpecificationUser
@@ -57,6 +85,27 @@ func (s *AndSpecification) IsSatisfiedBy(u *User) bool {
 	return true
 }
 
+func (s *AndSpecification) Children() []SpecificationUser {
+	return s.specs
+}
+
+// Explain reports the reasons of every failing clause
+func (s *AndSpecification) Explain(u *User) []string {
+	return reasonMessages(s.reasons(u))
+}
+
+func (s *AndSpecification) reasons(u *User) []reason {
+	var reasons []reason
+	for _, spec := range s.specs {
+		reasons = append(reasons, explainReasons(spec, u)...)
+	}
+	return reasons
+}
+
+func (s *AndSpecification) String() string {
+	return "And(" + joinSpecStrings(s.specs) + ")"
+}
+
 // Or
 type OrSpecification struct {
 	specs []SpecificationUser
@@ -76,6 +125,14 @@ func (s *OrSpecification) IsSatisfiedBy(u *User) bool {
 	return false
 }
 
+func (s *OrSpecification) Children() []SpecificationUser {
+	return s.specs
+}
+
+func (s *OrSpecification) String() string {
+	return "Or(" + joinSpecStrings(s.specs) + ")"
+}
+
 // Not
 type NotSpecification struct {
 	spec SpecificationUser
@@ -91,6 +148,14 @@ func (s *NotSpecification) IsSatisfiedBy(u *User) bool {
 	return !s.spec.IsSatisfiedBy(u)
 }
 
+func (s *NotSpecification) Inner() SpecificationUser {
+	return s.spec
+}
+
+func (s *NotSpecification) String() string {
+	return "Not(" + specString(s.spec) + ")"
+}
+
 //Specification type
 type TypeSpecification struct {
 	typ UserType
@@ -100,6 +165,10 @@ func (s *TypeSpecification) IsSatisfiedBy(u *User) bool {
 	return s.typ == u.Type
 }
 
+func (s *TypeSpecification) String() string {
+	return fmt.Sprintf("Type(%v)", s.typ)
+}
+
 //Specification name: too short
 type NameLengthSpecification struct {
 	l int
@@ -114,6 +183,10 @@ func (s *NameLengthSpecification) IsSatisfiedBy(u *User) bool {
 	return len(u.Name) <= s.l
 }
 
+func (s *NameLengthSpecification) String() string {
+	return fmt.Sprintf("NameShort(%d)", s.l)
+}
+
 // SpecificationUserName
 type NameSpecification struct {
 	name string
@@ -128,6 +201,10 @@ func (s *NameSpecification) IsSatisfiedBy(u *User) bool {
 	return strings.ToLower(u.Name) == s.name
 }
 
+func (s *NameSpecification) String() string {
+	return fmt.Sprintf("Name(%s)", s.name)
+}
+
 //SpecificationLocked
 type LockedSpecification struct{}
 
@@ -135,6 +212,10 @@ func (s *LockedSpecification) IsSatisfiedBy(u *User) bool {
 	return u.Locked
 }
 
+func (s *LockedSpecification) String() string {
+	return "Locked"
+}
+
 // Predefined rules
 var (
 	IsPersonal   = &TypeSpecification{typ: Personal}
@@ -151,20 +232,24 @@ var (
 	NotLocked = Not(Locked)
 
 	ValidNameNotAdmin = And(Not(AnyAdmin), NotLocked, Not(IsNameShort4))
+
+	EligibleForAutoUnlock = And(Locked, LockedBecause("inactive"), LockedLongerThan(90*24*time.Hour))
 )
 
 func UserIsSatisfiedBy(u *User, spec SpecificationUser) bool {
 	return spec.IsSatisfiedBy(u)
 }
 
-func checkAccess(spec SpecificationUser, name string, handler func()) func(*User) error {
+func checkAccess(spec SpecificationUser, name string, handler func(), hooks ...AccessHooks) func(*User) error {
 	return func(user *User) error {
 		if !spec.IsSatisfiedBy(user) {
+			if e, ok := spec.(Explainer); ok {
+				return fmt.Errorf("%s: access denied, user: %v: %s", name, user, strings.Join(e.Explain(user), "; "))
+			}
 			return fmt.Errorf("%s: access denied, user: %v", name, user)
 		}
 		fmt.Printf("%s: access granted, user: %v\n", name, user)
-		handler()
-		return nil
+		return runHandler(user, handler, hooks)
 	}
 }
 
@@ -217,4 +302,12 @@ func main() {
 		fmt.Println(err)
 	}
 
+	SuspiciousRename := And(NameChangedWithin(24*time.Hour), NotAdmin)
+	Renamed := &User{
+		Type:          Personal,
+		Name:          "B00Foo",
+		NameChangedAt: time.Now().Add(-time.Hour),
+	}
+	fmt.Printf("%s: Suspicious rename? %v\n", Renamed, UserIsSatisfiedBy(Renamed, SuspiciousRename))
+
 }
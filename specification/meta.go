@@ -0,0 +1,49 @@
+package main
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// MetaEquals: the metadata value at the path equals the value.
+//
+// The path is a list of keys separated by dots, e.g. "profile.department" reads
+// Metadata["profile"].(map[string]interface{})["department"]. Every segment but the last
+// must be a map[string]interface{}; there is no escaping of dots and no indexing of slices.
+// A missing segment or a non-map on the way is not satisfied. The values are compared
+// with reflect.DeepEqual, so 1 and 1.0 or 1 and "1" differ.
+type MetaEqualsSpecification struct {
+	path  []string
+	value interface{}
+}
+
+func MetaEquals(path string, value interface{}) *MetaEqualsSpecification {
+	return &MetaEqualsSpecification{
+		path:  strings.Split(path, "."),
+		value: value,
+	}
+}
+
+func (s *MetaEqualsSpecification) IsSatisfiedBy(u *User) bool {
+	v, ok := metaLookup(u.Metadata, s.path)
+	return ok && reflect.DeepEqual(v, s.value)
+}
+
+func (s *MetaEqualsSpecification) String() string {
+	return fmt.Sprintf("MetaEquals(%s, %v)", strings.Join(s.path, "."), s.value)
+}
+
+func metaLookup(m map[string]interface{}, path []string) (interface{}, bool) {
+	var v interface{} = m
+	for _, key := range path {
+		node, ok := v.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		if v, ok = node[key]; !ok {
+			return nil, false
+		}
+	}
+	return v, true
+}
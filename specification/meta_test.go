@@ -0,0 +1,46 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestMetaEquals(t *testing.T) {
+	u := &User{Metadata: map[string]interface{}{
+		"level": 3,
+		"tags":  []interface{}{"a", "b"},
+		"profile": map[string]interface{}{
+			"department": "ops",
+			"manager": map[string]interface{}{
+				"name": "boo",
+			},
+		},
+	}}
+	tests := []struct {
+		path  string
+		value interface{}
+		want  bool
+	}{
+		{"profile.department", "ops", true},
+		{"profile.manager.name", "boo", true},
+		{"level", 3, true},
+		{"tags", []interface{}{"a", "b"}, true},
+		{"profile.department", "dev", false},
+		{"profile.missing", "ops", false},
+		{"missing.department", "ops", false},
+		{"profile.manager.name.first", "boo", false},
+		{"level.value", 3, false},
+		{"tags.0", "a", false},
+		{"level", 3.0, false},
+		{"level", "3", false},
+		{"profile", "ops", false},
+	}
+	for _, tt := range tests {
+		s := MetaEquals(tt.path, tt.value)
+		if got := s.IsSatisfiedBy(u); got != tt.want {
+			t.Errorf("%s.IsSatisfiedBy = %v, want %v", s, got, tt.want)
+		}
+	}
+	if MetaEquals("profile.department", "ops").IsSatisfiedBy(&User{}) {
+		t.Errorf("user without metadata satisfies MetaEquals")
+	}
+}
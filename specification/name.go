@@ -0,0 +1,543 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/cases"
+	"golang.org/x/text/collate"
+	"golang.org/x/text/language"
+	"golang.org/x/text/unicode/norm"
+)
+
+var placeholderNames = map[string]struct{}{
+	"":          {},
+	"user":      {},
+	"test":      {},
+	"guest":     {},
+	"unknown":   {},
+	"anonymous": {},
+	"noname":    {},
+	"n/a":       {},
+}
+
+// Specification name: placeholder like "user", "test", "unknown" or empty
+type PlaceholderNameSpecification struct{}
+
+func (s *PlaceholderNameSpecification) IsSatisfiedBy(u *User) bool {
+	_, ok := placeholderNames[strings.ToLower(strings.TrimSpace(u.Name))]
+	return ok
+}
+
+func (s *PlaceholderNameSpecification) String() string {
+	return "PlaceholderName"
+}
+
+// scripts whose letters are easily confused with each other (Latin "a" and Cyrillic "а")
+var confusableScripts = []*unicode.RangeTable{
+	unicode.Latin,
+	unicode.Cyrillic,
+	unicode.Greek,
+	unicode.Armenian,
+}
+
+// Specification name: not mixing letters of lookalike scripts, e.g. Latin and Cyrillic
+type NameNotConfusableSpecification struct{}
+
+func NameNotConfusable() *NameNotConfusableSpecification {
+	return &NameNotConfusableSpecification{}
+}
+
+func (s *NameNotConfusableSpecification) IsSatisfiedBy(u *User) bool {
+	var script *unicode.RangeTable
+	for _, r := range u.Name {
+		for _, table := range confusableScripts {
+			if !unicode.Is(table, r) {
+				continue
+			}
+			if script != nil && script != table {
+				return false
+			}
+			script = table
+		}
+	}
+	return true
+}
+
+func (s *NameNotConfusableSpecification) String() string {
+	return "NameNotConfusable"
+}
+
+// Specification name: within maxDistance edits (Levenshtein) of the target, case-insensitive
+type NameSimilarSpecification struct {
+	target      []rune
+	maxDistance int
+}
+
+func NameSimilarTo(target string, maxDistance int) *NameSimilarSpecification {
+	return &NameSimilarSpecification{
+		target:      []rune(strings.ToLower(target)),
+		maxDistance: maxDistance,
+	}
+}
+
+func (s *NameSimilarSpecification) IsSatisfiedBy(u *User) bool {
+	return levenshtein([]rune(strings.ToLower(u.Name)), s.target, s.maxDistance) <= s.maxDistance
+}
+
+func (s *NameSimilarSpecification) String() string {
+	return fmt.Sprintf("NameSimilarTo(%s, %d)", string(s.target), s.maxDistance)
+}
+
+// levenshtein returns the edit distance of a and b,
+// or max+1 as soon as it is known to exceed max
+func levenshtein(a, b []rune, max int) int {
+	if d := len(a) - len(b); d > max || -d > max {
+		return max + 1
+	}
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		rowMin := cur[0]
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = minInt(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+			if cur[j] < rowMin {
+				rowMin = cur[j]
+			}
+		}
+		if rowMin > max {
+			return max + 1
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
+
+func minInt(values ...int) int {
+	m := values[0]
+	for _, v := range values[1:] {
+		if v < m {
+			m = v
+		}
+	}
+	return m
+}
+
+// Specification name: not reserved for the user's tenant, compared case-insensitively.
+// A nil callback means that nothing is reserved.
+type NameNotReservedSpecification struct {
+	reservedFor func(*User) []string
+}
+
+func NameNotReserved(reservedFor func(*User) []string) *NameNotReservedSpecification {
+	return &NameNotReservedSpecification{
+		reservedFor: reservedFor,
+	}
+}
+
+func (s *NameNotReservedSpecification) IsSatisfiedBy(u *User) bool {
+	if s.reservedFor == nil {
+		return true
+	}
+	for _, name := range s.reservedFor(u) {
+		if strings.EqualFold(name, u.Name) {
+			return false
+		}
+	}
+	return true
+}
+
+func (s *NameNotReservedSpecification) String() string {
+	return "NameNotReserved"
+}
+
+// Specification name: an anagram of the target, case-insensitive and ignoring spaces
+type NameAnagramSpecification struct {
+	target []rune
+}
+
+func NameAnagramOf(target string) *NameAnagramSpecification {
+	return &NameAnagramSpecification{
+		target: sortedRunes(target),
+	}
+}
+
+func (s *NameAnagramSpecification) IsSatisfiedBy(u *User) bool {
+	name := sortedRunes(u.Name)
+	if len(name) != len(s.target) {
+		return false
+	}
+	for i := range name {
+		if name[i] != s.target[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func (s *NameAnagramSpecification) String() string {
+	return fmt.Sprintf("NameAnagramOf(%s)", string(s.target))
+}
+
+// sortedRunes returns the lower-cased runes of s without spaces in sorted order,
+// two strings are anagrams if their sorted runes are equal
+func sortedRunes(s string) []rune {
+	runes := make([]rune, 0, len(s))
+	for _, r := range strings.ToLower(s) {
+		if !unicode.IsSpace(r) {
+			runes = append(runes, r)
+		}
+	}
+	sort.Slice(runes, func(i, j int) bool { return runes[i] < runes[j] })
+	return runes
+}
+
+// Specification name: title-cased, the name is unchanged by the Unicode title casing
+// of golang.org/x/text/cases ("Alex Smith", "Mary-Jane", not "alex smith" or "ALEX")
+type NameTitleCaseSpecification struct{}
+
+func NameTitleCase() *NameTitleCaseSpecification {
+	return &NameTitleCaseSpecification{}
+}
+
+// IsSatisfiedBy builds a Caser per call, a Caser is stateful and cannot be shared
+func (s *NameTitleCaseSpecification) IsSatisfiedBy(u *User) bool {
+	if strings.TrimSpace(u.Name) == "" {
+		return false
+	}
+	return cases.Title(language.Und).String(u.Name) == u.Name
+}
+
+func (s *NameTitleCaseSpecification) String() string {
+	return "NameTitleCase"
+}
+
+// Specification name: no leading or trailing whitespace
+type NameTrimmedSpecification struct{}
+
+func NameTrimmed() *NameTrimmedSpecification {
+	return &NameTrimmedSpecification{}
+}
+
+func (s *NameTrimmedSpecification) IsSatisfiedBy(u *User) bool {
+	return strings.TrimSpace(u.Name) == u.Name
+}
+
+func (s *NameTrimmedSpecification) String() string {
+	return "NameTrimmed"
+}
+
+// The letters that have no canonical decomposition into a base letter and marks
+var undecomposable = strings.NewReplacer(
+	"Ø", "O", "ø", "o", "Đ", "D", "đ", "d", "Ł", "L", "ł", "l", "Ħ", "H", "ħ", "h",
+	"Æ", "AE", "æ", "ae", "Œ", "OE", "œ", "oe", "ß", "ss",
+)
+
+// foldName decomposes the name (NFD), drops the combining marks and lower-cases it,
+// so "José", "Jose\u0301" and "JOSE" are all "jose" and "Nguyễn" is "nguyen".
+// The letters without a decomposition like "ø" or "ß" are replaced with their base letters.
+func foldName(name string) string {
+	name = strings.Map(func(r rune) rune {
+		if unicode.Is(unicode.Mn, r) {
+			return -1
+		}
+		return r
+	}, norm.NFD.String(name))
+	return strings.ToLower(undecomposable.Replace(name))
+}
+
+// Specification name: differs from the names of the existing users ignoring case and
+// diacritics, "José" collides with "Jose". The user itself may be among the existing ones.
+type NameUniqueNormalizedSpecification struct {
+	existing map[string][]*User
+}
+
+func NameUniqueNormalized(existing []*User) *NameUniqueNormalizedSpecification {
+	s := &NameUniqueNormalizedSpecification{
+		existing: make(map[string][]*User, len(existing)),
+	}
+	for _, u := range existing {
+		key := foldName(u.Name)
+		s.existing[key] = append(s.existing[key], u)
+	}
+	return s
+}
+
+func (s *NameUniqueNormalizedSpecification) IsSatisfiedBy(u *User) bool {
+	for _, other := range s.existing[foldName(u.Name)] {
+		if other != u {
+			return false
+		}
+	}
+	return true
+}
+
+func (s *NameUniqueNormalizedSpecification) String() string {
+	return fmt.Sprintf("NameUniqueNormalized(%d names)", len(s.existing))
+}
+
+// Specification name: the number of whitespace-separated words is at least (or at most) n
+type NameWordCountSpecification struct {
+	n      int
+	atMost bool
+}
+
+func NameWordCountAtLeast(n int) *NameWordCountSpecification {
+	return &NameWordCountSpecification{
+		n: n,
+	}
+}
+
+func NameWordCountAtMost(n int) *NameWordCountSpecification {
+	return &NameWordCountSpecification{
+		n:      n,
+		atMost: true,
+	}
+}
+
+func (s *NameWordCountSpecification) IsSatisfiedBy(u *User) bool {
+	count := len(strings.Fields(u.Name))
+	if s.atMost {
+		return count <= s.n
+	}
+	return count >= s.n
+}
+
+func (s *NameWordCountSpecification) String() string {
+	if s.atMost {
+		return fmt.Sprintf("NameWordCountAtMost(%d)", s.n)
+	}
+	return fmt.Sprintf("NameWordCountAtLeast(%d)", s.n)
+}
+
+// Specification name: no run of the same rune longer than maxRun ("aaaa" fails with 3)
+type NameNoRepeatsSpecification struct {
+	maxRun int
+}
+
+func NameNoRepeats(maxRun int) *NameNoRepeatsSpecification {
+	return &NameNoRepeatsSpecification{
+		maxRun: maxRun,
+	}
+}
+
+func (s *NameNoRepeatsSpecification) IsSatisfiedBy(u *User) bool {
+	run := 0
+	var prev rune
+	for i, r := range u.Name {
+		if i > 0 && r == prev {
+			run++
+		} else {
+			run = 1
+		}
+		if run > s.maxRun {
+			return false
+		}
+		prev = r
+	}
+	return true
+}
+
+func (s *NameNoRepeatsSpecification) String() string {
+	return fmt.Sprintf("NameNoRepeats(%d)", s.maxRun)
+}
+
+// Specification name: the initials, the upper-cased first letters of the words
+// ("Alex Smith" is "AS"), equal the upper-cased target
+type InitialsSpecification struct {
+	target string
+}
+
+func InitialsEqual(target string) *InitialsSpecification {
+	return &InitialsSpecification{
+		target: strings.ToUpper(target),
+	}
+}
+
+func (s *InitialsSpecification) IsSatisfiedBy(u *User) bool {
+	return initials(u.Name) == s.target
+}
+
+func (s *InitialsSpecification) String() string {
+	return fmt.Sprintf("InitialsEqual(%s)", s.target)
+}
+
+func initials(name string) string {
+	var sb strings.Builder
+	for _, word := range strings.Fields(name) {
+		r, _ := utf8.DecodeRuneInString(word)
+		sb.WriteRune(unicode.ToUpper(r))
+	}
+	return sb.String()
+}
+
+// Specification name: matches the regular expression
+type NameMatchSpecification struct {
+	re *regexp.Regexp
+}
+
+func NameMatches(pattern string) (*NameMatchSpecification, error) {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("NameMatches: %w", err)
+	}
+	return &NameMatchSpecification{
+		re: re,
+	}, nil
+}
+
+func (s *NameMatchSpecification) IsSatisfiedBy(u *User) bool {
+	return s.re.MatchString(u.Name)
+}
+
+func (s *NameMatchSpecification) String() string {
+	return fmt.Sprintf("NameMatches(%s)", s.re)
+}
+
+func (s *NameMatchSpecification) hashKey() string {
+	return s.re.String()
+}
+
+// Specification name: the Shannon entropy of the name runes is at least bits.
+// The entropy is in bits per rune, H = -Σ p(r) * log2(p(r)) where p(r) is the share
+// of the rune r in the name: "aaaa" has 0 bits, "abcd" has 2 bits.
+type NameEntropySpecification struct {
+	bits float64
+}
+
+func NameMinEntropy(bits float64) *NameEntropySpecification {
+	return &NameEntropySpecification{
+		bits: bits,
+	}
+}
+
+func (s *NameEntropySpecification) IsSatisfiedBy(u *User) bool {
+	return shannonEntropy(u.Name) >= s.bits
+}
+
+func (s *NameEntropySpecification) String() string {
+	return fmt.Sprintf("NameMinEntropy(%g)", s.bits)
+}
+
+func shannonEntropy(s string) float64 {
+	counts := make(map[rune]int)
+	total := 0
+	for _, r := range s {
+		counts[r]++
+		total++
+	}
+	h := 0.0
+	for _, n := range counts {
+		p := float64(n) / float64(total)
+		h -= p * math.Log2(p)
+	}
+	return h
+}
+
+// Specification name: sounds like the target, both have the same Soundex code
+// ("Smith" and "Smyth" are S530). American Soundex keeps the first letter and encodes
+// the following consonants by groups of similar sounds, so it suits English names
+// only: other letters than A-Z are ignored and other languages' phonetics are not known.
+type NameSoundsLikeSpecification struct {
+	code string
+}
+
+func NameSoundsLike(target string) *NameSoundsLikeSpecification {
+	return &NameSoundsLikeSpecification{
+		code: soundex(target),
+	}
+}
+
+func (s *NameSoundsLikeSpecification) IsSatisfiedBy(u *User) bool {
+	return s.code != "" && soundex(u.Name) == s.code
+}
+
+func (s *NameSoundsLikeSpecification) String() string {
+	return fmt.Sprintf("NameSoundsLike(%s)", s.code)
+}
+
+// soundex digits of the letters A-Z, 0 for the vowels and Y, '.' for H and W
+const soundexDigits = "0123012.02245501262301.202"
+
+// soundex returns the 4-character American Soundex code, empty if s has no letter A-Z.
+// Adjacent letters with the same digit are coded once, also when separated by H or W;
+// a vowel between them codes both.
+func soundex(s string) string {
+	code := make([]byte, 0, 4)
+	var last byte
+	for _, r := range strings.ToUpper(s) {
+		if r < 'A' || r > 'Z' {
+			continue
+		}
+		d := soundexDigits[r-'A']
+		if len(code) == 0 {
+			code = append(code, byte(r))
+			last = d
+			continue
+		}
+		switch d {
+		case '.':
+			continue
+		case '0':
+			last = d
+			continue
+		}
+		if d != last {
+			code = append(code, d)
+			if len(code) == 4 {
+				break
+			}
+		}
+		last = d
+	}
+	if len(code) == 0 {
+		return ""
+	}
+	for len(code) < 4 {
+		code = append(code, '0')
+	}
+	return string(code[:4])
+}
+
+// Specification name: the trimmed name sorts within [lo, hi] inclusive by the Unicode
+// root collation ignoring case, so "Bé" is within ["Ba", "Bz"] while its bytes sort
+// after "Bz", and "ba" is not before "Ba"
+type NameBetweenSpecification struct {
+	lo, hi string
+
+	mu       sync.Mutex        // a collator is not safe for concurrent use
+	collator *collate.Collator `hash:"-"`
+}
+
+func NameBetween(lo, hi string) *NameBetweenSpecification {
+	return &NameBetweenSpecification{
+		lo:       lo,
+		hi:       hi,
+		collator: collate.New(language.Und, collate.IgnoreCase),
+	}
+}
+
+func (s *NameBetweenSpecification) IsSatisfiedBy(u *User) bool {
+	name := strings.TrimSpace(u.Name)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.collator.CompareString(name, s.lo) >= 0 && s.collator.CompareString(name, s.hi) <= 0
+}
+
+func (s *NameBetweenSpecification) String() string {
+	return fmt.Sprintf("NameBetween(%s, %s)", s.lo, s.hi)
+}
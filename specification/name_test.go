@@ -0,0 +1,290 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+func TestNameUniqueNormalized(t *testing.T) {
+	existing := []*User{{Name: "José"}, {Name: "Nguyễn"}, {Name: "Søren"}}
+	s := NameUniqueNormalized(existing)
+	tests := []struct {
+		name   string
+		unique bool
+	}{
+		{"Jose", false},
+		{"JOSE", false},
+		{"José", false},
+		{"Nguyen", false},
+		{"Soren", false},
+		{"Josef", true},
+		{"Maria", true},
+	}
+	for _, tt := range tests {
+		if got := s.IsSatisfiedBy(&User{Name: tt.name}); got != tt.unique {
+			t.Errorf("%q unique = %v, want %v", tt.name, got, tt.unique)
+		}
+	}
+	if !s.IsSatisfiedBy(existing[0]) {
+		t.Errorf("an existing user collides with itself")
+	}
+}
+
+func TestNameBetweenCollation(t *testing.T) {
+	s := NameBetween("Ba", "Bz")
+	tests := []struct {
+		name string
+		want bool
+	}{
+		// "Bé" sorts after "Bz" by bytes but within the range by collation
+		{"Bé", true},
+		{"Béa", true},
+		{"ba", true},
+		{"Ba", true},
+		{"BZ", true},
+		{" Bo ", true},
+		{"Bza", false},
+		{"Ca", false},
+		{"Ab", false},
+	}
+	for _, tt := range tests {
+		if got := s.IsSatisfiedBy(&User{Name: tt.name}); got != tt.want {
+			t.Errorf("NameBetween(Ba, Bz) of %q = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+	if "Bé" <= "Bz" {
+		t.Fatalf("the byte ordering of the test names changed")
+	}
+}
+
+func TestNameNotConfusable(t *testing.T) {
+	RunSpecTests(t, NameNotConfusable(), []SpecCase{
+		{Name: "pure Latin", User: &User{Name: "paypal"}, Expected: true},
+		{Name: "pure Cyrillic", User: &User{Name: "\u0440\u0430\u0443"}, Expected: true},
+		{Name: "Latin with a Cyrillic a", User: &User{Name: "p\u0430ypal"}, Expected: false},
+		{Name: "Latin with a Greek omicron", User: &User{Name: "g\u03bfogle"}, Expected: false},
+		{Name: "Latin with digits and marks", User: &User{Name: "jose_1-é"}, Expected: true},
+		{Name: "empty", User: &User{}, Expected: true},
+	})
+}
+
+func TestNameSimilarTo(t *testing.T) {
+	s := NameSimilarTo("Alexander", 1)
+	tests := []struct {
+		name string
+		want bool
+	}{
+		{"Alexander", true},
+		{"alexander", true},
+		{"Alexandr", true},
+		{"Alaxander", true},
+		{"Alexanders", true},
+		{"Alxandr", false},
+		{"Alexandra1", false},
+		{"Alex", false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		if got := s.IsSatisfiedBy(&User{Name: tt.name}); got != tt.want {
+			t.Errorf("NameSimilarTo(Alexander, 1) of %q = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+	if !NameSimilarTo("boo", 0).IsSatisfiedBy(&User{Name: "BOO"}) {
+		t.Errorf("exact match ignoring case is not within distance 0")
+	}
+}
+
+func TestLevenshtein(t *testing.T) {
+	tests := []struct {
+		a, b string
+		max  int
+		want int
+	}{
+		{"", "", 3, 0},
+		{"kitten", "sitting", 3, 3},
+		{"kitten", "sitting", 2, 3},
+		{"flaw", "lawn", 5, 2},
+		{"ёлка", "елка", 1, 1},
+		{"a", "abcdef", 2, 3},
+	}
+	for _, tt := range tests {
+		if got := levenshtein([]rune(tt.a), []rune(tt.b), tt.max); got != tt.want {
+			t.Errorf("levenshtein(%q, %q, %d) = %d, want %d", tt.a, tt.b, tt.max, got, tt.want)
+		}
+	}
+}
+
+func TestNameNotReservedPerTenant(t *testing.T) {
+	reserved := map[string][]string{
+		"acme":   {"support", "billing"},
+		"globex": {"admin"},
+	}
+	s := NameNotReserved(func(u *User) []string {
+		tenant, _ := u.Metadata["tenant"].(string)
+		return reserved[tenant]
+	})
+	user := func(tenant, name string) *User {
+		return &User{Name: name, Metadata: map[string]interface{}{"tenant": tenant}}
+	}
+	RunSpecTests(t, s, []SpecCase{
+		{Name: "reserved in the tenant", User: user("acme", "support"), Expected: false},
+		{Name: "reserved ignoring case", User: user("acme", "Billing"), Expected: false},
+		{Name: "reserved in another tenant", User: user("globex", "support"), Expected: true},
+		{Name: "free name", User: user("acme", "boo"), Expected: true},
+		{Name: "unknown tenant", User: user("initech", "admin"), Expected: true},
+	})
+	RunSpecTests(t, NameNotReserved(nil), []SpecCase{
+		{Name: "nil callback", User: user("acme", "support"), Expected: true},
+	})
+}
+
+func TestNameAnagramOf(t *testing.T) {
+	RunSpecTests(t, NameAnagramOf("Listen"), []SpecCase{
+		{Name: "valid anagram", User: &User{Name: "Silent"}, Expected: true},
+		{Name: "anagram ignoring spaces", User: &User{Name: "en list"}, Expected: true},
+		{Name: "the target itself", User: &User{Name: "listen"}, Expected: true},
+		{Name: "same length non-anagram", User: &User{Name: "Silenc"}, Expected: false},
+		{Name: "repeated letter", User: &User{Name: "Silete"}, Expected: false},
+		{Name: "shorter", User: &User{Name: "Silen"}, Expected: false},
+		{Name: "longer", User: &User{Name: "Silents"}, Expected: false},
+	})
+	RunSpecTests(t, NameAnagramOf("Ёлка"), []SpecCase{
+		{Name: "multibyte anagram", User: &User{Name: "клаё"}, Expected: true},
+	})
+}
+
+func TestNameTitleCaseAndTrimmed(t *testing.T) {
+	RunSpecTests(t, NameTitleCase(), []SpecCase{
+		{User: &User{Name: "Alex Smith"}, Expected: true},
+		{User: &User{Name: "alex smith"}, Expected: false},
+		{User: &User{Name: "Alex smith"}, Expected: false},
+		{User: &User{Name: "ALEX"}, Expected: false},
+		{User: &User{Name: "Élodie Ångström"}, Expected: true},
+		{User: &User{Name: "Alex Smith "}, Expected: true},
+		{User: &User{Name: ""}, Expected: false},
+		{User: &User{Name: "  "}, Expected: false},
+		{User: &User{Name: "Mary-Jane Smith"}, Expected: true},
+		{User: &User{Name: "Mary-jane Smith"}, Expected: false},
+		{User: &User{Name: "O'neil"}, Expected: true},
+		{User: &User{Name: "O'Neil"}, Expected: false},
+		{User: &User{Name: "McDonald"}, Expected: false},
+		{User: &User{Name: "ǅemal"}, Expected: true},
+		{User: &User{Name: "ǆemal"}, Expected: false},
+	})
+	RunSpecTests(t, NameTrimmed(), []SpecCase{
+		{User: &User{Name: "Alex Smith"}, Expected: true},
+		{User: &User{Name: "Alex Smith "}, Expected: false},
+		{User: &User{Name: "\tAlex"}, Expected: false},
+	})
+	RunSpecTests(t, And(NameTitleCase(), NameTrimmed()), []SpecCase{
+		{User: &User{Name: "Alex Smith "}, Expected: false},
+		{User: &User{Name: "Alex Smith"}, Expected: true},
+	})
+}
+
+func TestNameWordCount(t *testing.T) {
+	RunSpecTests(t, NameWordCountAtLeast(2), []SpecCase{
+		{User: &User{Name: "Alex"}, Expected: false},
+		{User: &User{Name: "Alex Smith"}, Expected: true},
+		{User: &User{Name: "  Alex   Smith  "}, Expected: true},
+		{User: &User{Name: "Alex J. Smith"}, Expected: true},
+		{User: &User{Name: ""}, Expected: false},
+	})
+	RunSpecTests(t, NameWordCountAtMost(2), []SpecCase{
+		{User: &User{Name: "Alex"}, Expected: true},
+		{User: &User{Name: "Alex Smith"}, Expected: true},
+		{User: &User{Name: "Alex J. Smith"}, Expected: false},
+	})
+}
+
+func TestNameNoRepeats(t *testing.T) {
+	RunSpecTests(t, NameNoRepeats(3), []SpecCase{
+		{User: &User{Name: "aaa"}, Expected: true},
+		{User: &User{Name: "aaaa"}, Expected: false},
+		{User: &User{Name: "baaab"}, Expected: true},
+		{User: &User{Name: "baaaab"}, Expected: false},
+		{User: &User{Name: "aaabbbaaa"}, Expected: true},
+		{User: &User{Name: "ééé"}, Expected: true},
+		{User: &User{Name: "éééé"}, Expected: false},
+		{User: &User{Name: "日日日日"}, Expected: false},
+		{User: &User{Name: ""}, Expected: true},
+	})
+}
+
+func TestInitialsEqual(t *testing.T) {
+	RunSpecTests(t, InitialsEqual("as"), []SpecCase{
+		{User: &User{Name: "Alex Smith"}, Expected: true},
+		{User: &User{Name: "alex  smith"}, Expected: true},
+		{User: &User{Name: "Alex"}, Expected: false},
+		{User: &User{Name: "Alex J. Smith"}, Expected: false},
+	})
+	RunSpecTests(t, InitialsEqual("A"), []SpecCase{
+		{Name: "single word", User: &User{Name: "Alex"}, Expected: true},
+	})
+	RunSpecTests(t, InitialsEqual("ÉÅ"), []SpecCase{
+		{Name: "multibyte initials", User: &User{Name: "élodie ångström"}, Expected: true},
+	})
+	RunSpecTests(t, InitialsEqual(""), []SpecCase{
+		{Name: "empty name", User: &User{Name: " "}, Expected: true},
+	})
+}
+
+func TestNameMinEntropy(t *testing.T) {
+	tests := []struct {
+		name string
+		bits float64
+	}{
+		{"aaaa", 0},
+		{"abab", 1},
+		{"abcd", 2},
+		{"ééàà", 1},
+		{"", 0},
+	}
+	for _, tt := range tests {
+		if got := shannonEntropy(tt.name); math.Abs(got-tt.bits) > 1e-9 {
+			t.Errorf("shannonEntropy(%q) = %g, want %g", tt.name, got, tt.bits)
+		}
+	}
+	RunSpecTests(t, NameMinEntropy(2), []SpecCase{
+		{Name: "low entropy", User: &User{Name: "aaaaaab"}, Expected: false},
+		{Name: "at the threshold", User: &User{Name: "abcd"}, Expected: true},
+		{Name: "varied", User: &User{Name: "x7#Kq9!z"}, Expected: true},
+	})
+}
+
+func TestNameSoundsLike(t *testing.T) {
+	tests := []struct {
+		name string
+		code string
+	}{
+		{"Robert", "R163"},
+		{"Rupert", "R163"},
+		{"Rubin", "R150"},
+		{"Ashcraft", "A261"},
+		{"Ashcroft", "A261"},
+		{"Tymczak", "T522"},
+		{"Pfister", "P236"},
+		{"Honeyman", "H555"},
+		{"Lee", "L000"},
+		{"smith", "S530"},
+		{"O'Hara", "O600"},
+		{"", ""},
+		{"123", ""},
+	}
+	for _, tt := range tests {
+		if got := soundex(tt.name); got != tt.code {
+			t.Errorf("soundex(%q) = %q, want %q", tt.name, got, tt.code)
+		}
+	}
+
+	RunSpecTests(t, NameSoundsLike("Smith"), []SpecCase{
+		{User: &User{Name: "Smith"}, Expected: true},
+		{User: &User{Name: "Smyth"}, Expected: true},
+		{Name: "C coded like the first letter S", User: &User{Name: "SCHMIDT"}, Expected: true},
+		{User: &User{Name: "Smithers"}, Expected: false},
+		{User: &User{Name: "Jones"}, Expected: false},
+	})
+	RunSpecTests(t, NameSoundsLike("42"), []SpecCase{
+		{Name: "target without letters", User: &User{Name: "007"}, Expected: false},
+	})
+}
@@ -0,0 +1,221 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// identifiers of the predefined rules known by Parse, compared case-insensitively
+var parseIdents = map[string]SpecificationUser{
+	"ispersonal":        IsPersonal,
+	"isadmin":           IsAdmin,
+	"issuperadmin":      IsSuperAdmin,
+	"anyadmin":          AnyAdmin,
+	"notadmin":          NotAdmin,
+	"notsuperadmin":     NotSuperAdmin,
+	"isnameshort4":      IsNameShort4,
+	"locked":            Locked,
+	"notlocked":         NotLocked,
+	"validnamenotadmin": ValidNameNotAdmin,
+}
+
+// Parse builds a specification from an expression like
+//
+//	anyAdmin AND NOT (locked OR isNameShort4)
+//
+// The operands are the identifiers of the predefined rules (case-insensitive) or
+// the names of the registered rules and aliases (case-sensitive), the keywords are
+// case-insensitive. An alias is expanded into its subtree, a cycle of aliases is
+// an error. The precedence is NOT > AND > OR, as in most languages, so
+// "a OR b AND c" is "a OR (b AND c)" and "NOT a AND b" is "(NOT a) AND b";
+// parentheses override it. A chain of the same operator becomes one And/Or node.
+func Parse(expr string) (SpecificationUser, error) {
+	return parse(expr, nil)
+}
+
+// parse parses the expression, resolving is the chain of the aliases being expanded
+func parse(expr string, resolving []string) (SpecificationUser, error) {
+	tokens, err := tokenize(expr)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{tokens: tokens, resolving: resolving}
+	spec, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t != nil {
+		return nil, fmt.Errorf("parse: unexpected %q at %d", t.text, t.pos)
+	}
+	return spec, nil
+}
+
+type token struct {
+	text string
+	pos  int
+}
+
+func tokenize(expr string) ([]token, error) {
+	var tokens []token
+	runes := []rune(expr)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '(' || r == ')':
+			tokens = append(tokens, token{text: string(r), pos: i})
+			i++
+		case isIdentRune(r):
+			start := i
+			for i < len(runes) && isIdentRune(runes[i]) {
+				i++
+			}
+			tokens = append(tokens, token{text: string(runes[start:i]), pos: start})
+		default:
+			return nil, fmt.Errorf("parse: unexpected %q at %d", r, i)
+		}
+	}
+	return tokens, nil
+}
+
+func isIdentRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_'
+}
+
+type parser struct {
+	tokens    []token
+	pos       int
+	resolving []string
+}
+
+func (p *parser) peek() *token {
+	if p.pos < len(p.tokens) {
+		return &p.tokens[p.pos]
+	}
+	return nil
+}
+
+// keyword consumes the next token if it is the keyword
+func (p *parser) keyword(kw string) bool {
+	if t := p.peek(); t != nil && strings.EqualFold(t.text, kw) {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *parser) parseOr() (SpecificationUser, error) {
+	return p.parseChain("OR", p.parseAnd, func(specs []SpecificationUser) SpecificationUser {
+		return Or(specs...)
+	})
+}
+
+func (p *parser) parseAnd() (SpecificationUser, error) {
+	return p.parseChain("AND", p.parseNot, func(specs []SpecificationUser) SpecificationUser {
+		return And(specs...)
+	})
+}
+
+func (p *parser) parseChain(kw string, operand func() (SpecificationUser, error), join func([]SpecificationUser) SpecificationUser) (SpecificationUser, error) {
+	spec, err := operand()
+	if err != nil {
+		return nil, err
+	}
+	specs := []SpecificationUser{spec}
+	for p.keyword(kw) {
+		spec, err := operand()
+		if err != nil {
+			return nil, err
+		}
+		specs = append(specs, spec)
+	}
+	if len(specs) == 1 {
+		return specs[0], nil
+	}
+	return join(specs), nil
+}
+
+func (p *parser) parseNot() (SpecificationUser, error) {
+	if p.keyword("NOT") {
+		spec, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return Not(spec), nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *parser) parsePrimary() (SpecificationUser, error) {
+	t := p.peek()
+	if t == nil {
+		return nil, fmt.Errorf("parse: unexpected end of expression")
+	}
+	p.pos++
+	switch {
+	case t.text == "(":
+		spec, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if !p.keyword(")") {
+			return nil, fmt.Errorf("parse: missing ) for ( at %d", t.pos)
+		}
+		return spec, nil
+	case t.text == ")", isKeyword(t.text):
+		return nil, fmt.Errorf("parse: unexpected %q at %d", t.text, t.pos)
+	}
+	if spec, ok := parseIdents[strings.ToLower(t.text)]; ok {
+		return spec, nil
+	}
+	r, ok := lookupRule(t.text)
+	if !ok {
+		return nil, fmt.Errorf("parse: unknown identifier %q at %d", t.text, t.pos)
+	}
+	if r.spec != nil {
+		return r.spec, nil
+	}
+	return parseAlias(t.text, r.expr, p.resolving)
+}
+
+func parseAlias(name, expr string, resolving []string) (SpecificationUser, error) {
+	for i, n := range resolving {
+		if n == name {
+			cycle := append(append([]string{}, resolving[i:]...), name)
+			return nil, fmt.Errorf("parse: cyclic alias %s", strings.Join(cycle, " -> "))
+		}
+	}
+	resolving = append(resolving[:len(resolving):len(resolving)], name)
+	spec, err := parse(expr, resolving)
+	if err != nil {
+		if len(resolving) == 1 {
+			return nil, fmt.Errorf("alias %s: %w", name, err)
+		}
+		return nil, err
+	}
+	return spec, nil
+}
+
+func isKeyword(s string) bool {
+	return strings.EqualFold(s, "AND") || strings.EqualFold(s, "OR") || strings.EqualFold(s, "NOT")
+}
+
+// ReplEvaluate parses the expression and evaluates it for the user, the trace is the
+// FormatTree of the evaluation followed by the Explain reasons of a failure,
+// for "try your rule" tools. A parse error is returned as is.
+func ReplEvaluate(expr string, u *User) (bool, string, error) {
+	spec, err := Parse(expr)
+	if err != nil {
+		return false, "", err
+	}
+	r := Evaluate(spec, u)
+	trace := FormatTree(r)
+	if !r.Ok {
+		for _, reason := range Explain(spec, u) {
+			trace += "- " + reason + "\n"
+		}
+	}
+	return r.Ok, trace, nil
+}
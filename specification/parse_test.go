@@ -0,0 +1,184 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		expr string
+		want string
+	}{
+		{"isAdmin", "Type(ADMIN)"},
+		{"ISADMIN", "Type(ADMIN)"},
+		{"isAdmin OR locked AND isNameShort4", "Or(Type(ADMIN), And(Locked, NameShort(4)))"},
+		{"NOT isAdmin AND locked", "And(Not(Type(ADMIN)), Locked)"},
+		{"not not locked", "Not(Not(Locked))"},
+		{"(isAdmin OR locked) AND notLocked", "And(Or(Type(ADMIN), Locked), Not(Locked))"},
+		{"isAdmin and locked AND anyAdmin", "And(Type(ADMIN), Locked, Or(Type(ADMIN), Type(SUPER ADMIN)))"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			spec, err := Parse(tt.expr)
+			if err != nil {
+				t.Fatalf("Parse: %v", err)
+			}
+			if got := specString(spec); got != tt.want {
+				t.Errorf("Parse = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		expr string
+		err  string
+	}{
+		{"", "parse: unexpected end of expression"},
+		{"isAdmin AND", "parse: unexpected end of expression"},
+		{"(isAdmin", "parse: missing ) for ( at 0"},
+		{"isAdmin)", `parse: unexpected ")" at 7`},
+		{"AND isAdmin", `parse: unexpected "AND" at 0`},
+		{"foo", `parse: unknown identifier "foo" at 0`},
+		{"isAdmin & locked", "parse: unexpected '&' at 8"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			_, err := Parse(tt.expr)
+			if err == nil || err.Error() != tt.err {
+				t.Errorf("Parse error = %v, want %s", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestParseAlias(t *testing.T) {
+	defer SnapshotRegistry()()
+	DefineRule("validUser", ValidNameNotAdmin)
+	DefineAlias("staff", "anyAdmin AND NOT locked")
+	DefineAlias("activeStaff", "staff AND NOT isNameShort4")
+
+	tests := []struct {
+		expr string
+		want string
+	}{
+		{"validUser AND NOT locked", "And(" + specString(ValidNameNotAdmin) + ", Not(Locked))"},
+		{"staff", "And(Or(Type(ADMIN), Type(SUPER ADMIN)), Not(Locked))"},
+		{"activeStaff OR isAdmin", "Or(And(And(Or(Type(ADMIN), Type(SUPER ADMIN)), Not(Locked)), Not(NameShort(4))), Type(ADMIN))"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			spec, err := Parse(tt.expr)
+			if err != nil {
+				t.Fatalf("Parse: %v", err)
+			}
+			if got := specString(spec); got != tt.want {
+				t.Errorf("Parse = %s, want %s", got, tt.want)
+			}
+		})
+	}
+
+	// the rule names are case-sensitive
+	if _, err := Parse("VALIDUSER"); err == nil {
+		t.Errorf("Parse(VALIDUSER) resolved a case-sensitive rule name")
+	}
+}
+
+func TestParseAliasCycle(t *testing.T) {
+	defer SnapshotRegistry()()
+	DefineAlias("a", "isAdmin AND b")
+	DefineAlias("b", "NOT c")
+	DefineAlias("c", "locked OR a")
+	DefineAlias("self", "self")
+
+	tests := []struct {
+		expr string
+		err  string
+	}{
+		{"a", "alias a: parse: cyclic alias a -> b -> c -> a"},
+		{"isAdmin OR b", "alias b: parse: cyclic alias b -> c -> a -> b"},
+		{"self", "alias self: parse: cyclic alias self -> self"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			_, err := Parse(tt.expr)
+			if err == nil || err.Error() != tt.err {
+				t.Errorf("Parse error = %v, want %s", err, tt.err)
+			}
+		})
+	}
+
+	if _, _, err := Rule("a"); err == nil {
+		t.Errorf("Rule(a) of a cyclic alias returned no error")
+	}
+}
+
+func TestParsePrecedence(t *testing.T) {
+	domain := typeDomain()
+	for _, name := range []string{"boo", "alexander"} {
+		domain = append(domain, &User{Type: Admin, Name: name}, &User{Type: Personal, Name: name, Locked: true})
+	}
+	a, b, c := IsAdmin, Locked, IsNameShort4
+	tests := []struct {
+		expr string
+		want SpecificationUser
+	}{
+		{"isAdmin OR locked AND isNameShort4", Or(a, And(b, c))},
+		{"isAdmin AND locked OR isNameShort4", Or(And(a, b), c)},
+		{"(isAdmin OR locked) AND isNameShort4", And(Or(a, b), c)},
+		{"isAdmin AND (locked OR isNameShort4)", And(a, Or(b, c))},
+		{"NOT isAdmin OR locked", Or(Not(a), b)},
+		{"NOT (isAdmin OR locked)", Not(Or(a, b))},
+	}
+	for _, tt := range tests {
+		spec, err := Parse(tt.expr)
+		if err != nil {
+			t.Fatalf("Parse(%s): %v", tt.expr, err)
+		}
+		if !SemanticallyEqual(spec, tt.want, domain) {
+			t.Errorf("Parse(%s) = %s, want %s", tt.expr, specString(spec), specString(tt.want))
+		}
+	}
+	// the parentheses change the result
+	grouped, _ := Parse("(isAdmin OR locked) AND isNameShort4")
+	plain, _ := Parse("isAdmin OR locked AND isNameShort4")
+	if SemanticallyEqual(grouped, plain, domain) {
+		t.Errorf("the parentheses do not override the precedence")
+	}
+}
+
+func TestReplEvaluate(t *testing.T) {
+	tests := []struct {
+		name  string
+		user  *User
+		ok    bool
+		trace string
+	}{
+		{"granted", &User{Type: Admin, Name: "boo"}, true, `✓ AND
+  ✓ Type(ADMIN)
+  ✓ NOT
+    ✗ Locked
+`},
+		{"denied with the reasons", &User{Type: Admin, Name: "boo", Locked: true}, false, `✗ AND
+  ✓ Type(ADMIN)
+  ✗ NOT
+    ✓ Locked
+- Not(Locked): not satisfied
+`},
+	}
+	for _, tt := range tests {
+		ok, trace, err := ReplEvaluate("isAdmin AND NOT locked", tt.user)
+		if err != nil {
+			t.Fatalf("%s: ReplEvaluate: %v", tt.name, err)
+		}
+		if ok != tt.ok || trace != tt.trace {
+			t.Errorf("%s: ReplEvaluate = %v,\n%s\nwant %v,\n%s", tt.name, ok, trace, tt.ok, tt.trace)
+		}
+	}
+
+	ok, trace, err := ReplEvaluate("isAdmin AND", &User{Type: Admin})
+	if ok || trace != "" || err == nil || err.Error() != "parse: unexpected end of expression" {
+		t.Errorf("ReplEvaluate of a parse error = %v, %q, %v", ok, trace, err)
+	}
+}
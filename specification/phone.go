@@ -0,0 +1,65 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// normalizePhone strips the spaces, dashes, dots and parentheses of an E.164-like number:
+// "+1 (415) 555-0100" is "+14155550100". The number must start with + followed by
+// 8 to 15 digits, otherwise ok is false.
+func normalizePhone(phone string) (normalized string, ok bool) {
+	var sb strings.Builder
+	for i, r := range strings.TrimSpace(phone) {
+		switch {
+		case r == '+' && i == 0:
+			sb.WriteRune(r)
+		case r >= '0' && r <= '9':
+			sb.WriteRune(r)
+		case r == ' ' || r == '-' || r == '.' || r == '(' || r == ')':
+		default:
+			return "", false
+		}
+	}
+	normalized = sb.String()
+	if !strings.HasPrefix(normalized, "+") || len(normalized) < 9 || len(normalized) > 16 {
+		return "", false
+	}
+	return normalized, true
+}
+
+// ValidPhone: the phone is a valid number with a country code
+type ValidPhoneSpecification struct{}
+
+func ValidPhone() *ValidPhoneSpecification {
+	return &ValidPhoneSpecification{}
+}
+
+func (s *ValidPhoneSpecification) IsSatisfiedBy(u *User) bool {
+	_, ok := normalizePhone(u.Phone)
+	return ok
+}
+
+func (s *ValidPhoneSpecification) String() string {
+	return "ValidPhone"
+}
+
+// PhoneInCountry: the phone is a valid number with the country calling code, e.g. "1" or "+44"
+type PhoneCountrySpecification struct {
+	prefix string
+}
+
+func PhoneInCountry(cc string) *PhoneCountrySpecification {
+	return &PhoneCountrySpecification{
+		prefix: "+" + strings.TrimPrefix(cc, "+"),
+	}
+}
+
+func (s *PhoneCountrySpecification) IsSatisfiedBy(u *User) bool {
+	phone, ok := normalizePhone(u.Phone)
+	return ok && strings.HasPrefix(phone, s.prefix)
+}
+
+func (s *PhoneCountrySpecification) String() string {
+	return fmt.Sprintf("PhoneInCountry(%s)", s.prefix)
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestNormalizePhone(t *testing.T) {
+	tests := []struct {
+		phone string
+		want  string
+		ok    bool
+	}{
+		{"+1 (415) 555-0100", "+14155550100", true},
+		{" +44 20.7946.0018 ", "+442079460018", true},
+		{"+14155550100", "+14155550100", true},
+		{"(415) 555-0100", "", false},
+		{"4155550100", "", false},
+		{"+1 415 5", "", false},
+		{"+1234567890123456", "", false},
+		{"+1 415 555 0100 ext 1", "", false},
+		{"+1+4155550100", "", false},
+		{"", "", false},
+	}
+	for _, tt := range tests {
+		got, ok := normalizePhone(tt.phone)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("normalizePhone(%q) = %q, %v, want %q, %v", tt.phone, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestPhoneSpecifications(t *testing.T) {
+	RunSpecTests(t, ValidPhone(), []SpecCase{
+		{Name: "formatted with a country code", User: &User{Phone: "+1 (415) 555-0100"}, Expected: true},
+		{Name: "bare local number", User: &User{Phone: "415-555-0100"}, Expected: false},
+		{Name: "no phone", User: &User{}, Expected: false},
+	})
+	RunSpecTests(t, PhoneInCountry("1"), []SpecCase{
+		{Name: "US number", User: &User{Phone: "+1 (415) 555-0100"}, Expected: true},
+		{Name: "UK number", User: &User{Phone: "+44 20 7946 0018"}, Expected: false},
+		{Name: "bare local number", User: &User{Phone: "4155550100"}, Expected: false},
+	})
+	RunSpecTests(t, PhoneInCountry("+44"), []SpecCase{
+		{Name: "UK number", User: &User{Phone: "+44 20 7946 0018"}, Expected: true},
+	})
+}
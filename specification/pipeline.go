@@ -0,0 +1,75 @@
+package main
+
+import "strings"
+
+// Stage is a step of a Pipeline: the specification gates the stage, the action runs
+// after it passes and may store data for the next stages in the shared state.
+// A nil action does nothing.
+type Stage struct {
+	Name   string
+	Spec   SpecificationUser
+	Action func(u *User, state map[string]interface{}) error
+}
+
+// PipelineResult is the outcome of a run of a Pipeline
+type PipelineResult struct {
+	Ok bool
+	// Stopped is the index of the stage which stopped the pipeline, -1 if all passed
+	Stopped int
+	// Stage is the name of that stage
+	Stage string
+	// Err is the error of its action, nil if its specification failed
+	Err error
+	// State is the state shared by the stages
+	State map[string]interface{}
+}
+
+// Pipeline evaluates the stages in order (e.g. authenticate, authorize, rate-limit)
+// and stops at the first stage whose specification fails or whose action returns
+// an error. The actions run on every evaluation, IsSatisfiedBy included.
+type PipelineSpecification struct {
+	stages []Stage
+}
+
+func Pipeline(stages []Stage) *PipelineSpecification {
+	return &PipelineSpecification{
+		stages: stages,
+	}
+}
+
+// Run evaluates the stages for the user with a new state
+func (s *PipelineSpecification) Run(u *User) PipelineResult {
+	state := make(map[string]interface{})
+	for i, stage := range s.stages {
+		if !stage.Spec.IsSatisfiedBy(u) {
+			return PipelineResult{Stopped: i, Stage: stage.Name, State: state}
+		}
+		if stage.Action == nil {
+			continue
+		}
+		if err := stage.Action(u, state); err != nil {
+			return PipelineResult{Stopped: i, Stage: stage.Name, Err: err, State: state}
+		}
+	}
+	return PipelineResult{Ok: true, Stopped: -1, State: state}
+}
+
+func (s *PipelineSpecification) IsSatisfiedBy(u *User) bool {
+	return s.Run(u).Ok
+}
+
+func (s *PipelineSpecification) Children() []SpecificationUser {
+	specs := make([]SpecificationUser, len(s.stages))
+	for i, stage := range s.stages {
+		specs[i] = stage.Spec
+	}
+	return specs
+}
+
+func (s *PipelineSpecification) String() string {
+	parts := make([]string, len(s.stages))
+	for i, stage := range s.stages {
+		parts[i] = stage.Name + ": " + specString(stage.Spec)
+	}
+	return "Pipeline(" + strings.Join(parts, ", ") + ")"
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestCompilePipelineRunsActions(t *testing.T) {
+	runs := 0
+	p := Pipeline([]Stage{{
+		Name:   "authorize",
+		Spec:   AnyAdmin,
+		Action: func(u *User, state map[string]interface{}) error { runs++; return nil },
+	}})
+	fn := Compile(p)
+	if runs != 0 {
+		t.Fatalf("Compile ran the action %d times", runs)
+	}
+	u := &User{Type: Admin}
+	for i := 0; i < 2; i++ {
+		if !fn(u) {
+			t.Fatalf("compiled pipeline denied an admin")
+		}
+	}
+	if runs != 2 {
+		t.Errorf("action ran %d times over two calls, want 2", runs)
+	}
+}
+
+func TestPipelineRun(t *testing.T) {
+	errRateLimited := errors.New("rate limited")
+	p := Pipeline([]Stage{
+		{Name: "authenticate", Spec: NotLocked, Action: func(u *User, state map[string]interface{}) error {
+			state["user"] = u.Name
+			return nil
+		}},
+		{Name: "authorize", Spec: AnyAdmin, Action: func(u *User, state map[string]interface{}) error {
+			state["role"] = u.Type.String() + " " + state["user"].(string)
+			return nil
+		}},
+		{Name: "rate-limit", Spec: And(), Action: func(u *User, state map[string]interface{}) error {
+			if u.Name == "spammer" {
+				return errRateLimited
+			}
+			state["limited"] = false
+			return nil
+		}},
+	})
+	tests := []struct {
+		name string
+		user *User
+		want PipelineResult
+	}{
+		{"passes fully", &User{Type: Admin, Name: "boo"}, PipelineResult{
+			Ok: true, Stopped: -1,
+			State: map[string]interface{}{"user": "boo", "role": "ADMIN boo", "limited": false},
+		}},
+		{"stops at stage two", &User{Type: Personal, Name: "boo"}, PipelineResult{
+			Stopped: 1, Stage: "authorize",
+			State: map[string]interface{}{"user": "boo"},
+		}},
+		{"stops at the first stage", &User{Type: Admin, Name: "boo", Locked: true}, PipelineResult{
+			Stopped: 0, Stage: "authenticate",
+			State: map[string]interface{}{},
+		}},
+		{"action error stops", &User{Type: Admin, Name: "spammer"}, PipelineResult{
+			Stopped: 2, Stage: "rate-limit", Err: errRateLimited,
+			State: map[string]interface{}{"user": "spammer", "role": "ADMIN spammer"},
+		}},
+	}
+	for _, tt := range tests {
+		if got := p.Run(tt.user); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: Run = %+v, want %+v", tt.name, got, tt.want)
+		}
+		if got := p.IsSatisfiedBy(tt.user); got != tt.want.Ok {
+			t.Errorf("%s: IsSatisfiedBy = %v, want %v", tt.name, got, tt.want.Ok)
+		}
+	}
+}
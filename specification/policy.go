@@ -0,0 +1,29 @@
+package main
+
+// EvaluatePolicy decides like AWS IAM: a matching deny rule always wins regardless
+// of the order, otherwise a matching allow rule allows, otherwise access is denied
+// by default. The reason names the deciding rule.
+func EvaluatePolicy(u *User, allows, denies []SpecificationUser) (allowed bool, reason string) {
+	for _, spec := range denies {
+		if spec.IsSatisfiedBy(u) {
+			return false, "explicit deny: " + specString(spec)
+		}
+	}
+	for _, spec := range allows {
+		if spec.IsSatisfiedBy(u) {
+			return true, "allow: " + specString(spec)
+		}
+	}
+	return false, "default deny"
+}
+
+// Coalesce returns the index of the first specification satisfied by the user,
+// the later ones are not evaluated. It returns -1, false if none is satisfied.
+func Coalesce(u *User, specs ...SpecificationUser) (matched int, ok bool) {
+	for i, spec := range specs {
+		if spec.IsSatisfiedBy(u) {
+			return i, true
+		}
+	}
+	return -1, false
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestEvaluatePolicy(t *testing.T) {
+	allows := []SpecificationUser{AnyAdmin, Name("guest")}
+	denies := []SpecificationUser{Locked, Name("mallory")}
+	tests := []struct {
+		name    string
+		user    *User
+		allowed bool
+		reason  string
+	}{
+		{"deny overrides allow", &User{Type: Admin, Locked: true}, false, "explicit deny: Locked"},
+		{"second deny overrides allow", &User{Type: Admin, Name: "mallory"}, false, "explicit deny: Name(mallory)"},
+		{"allow", &User{Type: SuperAdmin}, true, "allow: Or(Type(ADMIN), Type(SUPER ADMIN))"},
+		{"second allow", &User{Name: "Guest"}, true, "allow: Name(guest)"},
+		{"default deny", &User{Name: "boo"}, false, "default deny"},
+	}
+	for _, tt := range tests {
+		allowed, reason := EvaluatePolicy(tt.user, allows, denies)
+		if allowed != tt.allowed || reason != tt.reason {
+			t.Errorf("%s: EvaluatePolicy = %v, %q, want %v, %q", tt.name, allowed, reason, tt.allowed, tt.reason)
+		}
+	}
+	if allowed, reason := EvaluatePolicy(&User{}, nil, nil); allowed || reason != "default deny" {
+		t.Errorf("EvaluatePolicy without rules = %v, %q", allowed, reason)
+	}
+}
+
+func TestCoalesce(t *testing.T) {
+	specs := []SpecificationUser{IsSuperAdmin, AnyAdmin, NotLocked}
+	tests := []struct {
+		name    string
+		user    *User
+		matched int
+		ok      bool
+	}{
+		{"first applies", &User{Type: SuperAdmin}, 0, true},
+		{"an earlier one wins over a later one", &User{Type: Admin}, 1, true},
+		{"last applies", &User{Type: Personal}, 2, true},
+		{"none applies", &User{Type: Personal, Locked: true}, -1, false},
+	}
+	for _, tt := range tests {
+		if matched, ok := Coalesce(tt.user, specs...); matched != tt.matched || ok != tt.ok {
+			t.Errorf("%s: Coalesce = %d, %v, want %d, %v", tt.name, matched, ok, tt.matched, tt.ok)
+		}
+	}
+	if matched, ok := Coalesce(&User{}); matched != -1 || ok {
+		t.Errorf("Coalesce without specifications = %d, %v, want -1, false", matched, ok)
+	}
+
+	// the specifications after the match are not evaluated
+	first, second := &countingSpec{ok: true}, &countingSpec{ok: true}
+	Coalesce(&User{}, first, second)
+	if first.calls != 1 || second.calls != 0 {
+		t.Errorf("evaluated %d and %d times, want 1 and 0", first.calls, second.calls)
+	}
+}
@@ -0,0 +1,43 @@
+package main
+
+// LeafStats counts the evaluations of a leaf over a set of users
+type LeafStats struct {
+	Evaluated int
+	Passed    int
+}
+
+// Profile fully evaluates the specification (see Evaluate) for every user and returns
+// the stats of each leaf by its String. A leaf referenced several times in the tree
+// is evaluated once per user, so it is counted once.
+func Profile(spec SpecificationUser, users []*User) map[string]LeafStats {
+	stats := make(map[string]LeafStats)
+	for _, u := range users {
+		profileLeaves(Evaluate(spec, u), stats, make(map[string]bool))
+	}
+	return stats
+}
+
+func profileLeaves(r *Result, stats map[string]LeafStats, counted map[string]bool) {
+	if len(r.Children) == 0 {
+		switch r.Spec.(type) {
+		case *AndSpecification, *OrSpecification, combiner:
+			// an empty composite is a constant, not a leaf
+			return
+		}
+		name := specString(r.Spec)
+		if counted[name] {
+			return
+		}
+		counted[name] = true
+		s := stats[name]
+		s.Evaluated++
+		if r.Ok {
+			s.Passed++
+		}
+		stats[name] = s
+		return
+	}
+	for _, child := range r.Children {
+		profileLeaves(child, stats, counted)
+	}
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestProfile(t *testing.T) {
+	users := []*User{
+		{Type: Admin, Name: "boo"},
+		{Type: Personal, Name: "alexander", Locked: true},
+		{Type: Personal, Name: "foo"},
+	}
+	tests := []struct {
+		name string
+		spec SpecificationUser
+		want map[string]LeafStats
+	}{
+		{"every leaf of every user", And(NotLocked, Or(IsAdmin, IsNameShort4)), map[string]LeafStats{
+			"Locked":       {Evaluated: 3, Passed: 1},
+			"Type(ADMIN)":  {Evaluated: 3, Passed: 1},
+			"NameShort(4)": {Evaluated: 3, Passed: 2},
+		}},
+		{"a repeated leaf is counted once per user", Or(Locked, And(IsNameShort4, Not(Locked))), map[string]LeafStats{
+			"Locked":       {Evaluated: 3, Passed: 1},
+			"NameShort(4)": {Evaluated: 3, Passed: 2},
+		}},
+		{"an empty composite is not a leaf", And(IsAdmin, Or()), map[string]LeafStats{
+			"Type(ADMIN)": {Evaluated: 3, Passed: 1},
+		}},
+	}
+	for _, tt := range tests {
+		if got := Profile(tt.spec, users); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: Profile = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+	if got := Profile(IsAdmin, nil); len(got) != 0 {
+		t.Errorf("Profile without users = %v", got)
+	}
+}
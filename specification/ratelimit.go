@@ -0,0 +1,65 @@
+package main
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// RateLimited: the wrapped specification passes and the key has not exceeded
+// the limit of grants within the sliding window
+type RateLimitedSpecification struct {
+	spec   SpecificationUser
+	limit  int
+	window time.Duration
+	keyFn  func(*User) string
+	now    func() time.Time
+
+	mu     sync.Mutex
+	grants map[string][]time.Time `hash:"-"`
+}
+
+func RateLimited(spec SpecificationUser, limit int, window time.Duration, keyFn func(*User) string) *RateLimitedSpecification {
+	return &RateLimitedSpecification{
+		spec:   spec,
+		limit:  limit,
+		window: window,
+		keyFn:  keyFn,
+		now:    time.Now,
+		grants: make(map[string][]time.Time),
+	}
+}
+
+// WithClock replaces the source of the current time
+func (s *RateLimitedSpecification) WithClock(now func() time.Time) *RateLimitedSpecification {
+	s.now = now
+	return s
+}
+
+func (s *RateLimitedSpecification) IsSatisfiedBy(u *User) bool {
+	if !s.spec.IsSatisfiedBy(u) {
+		return false
+	}
+	key := s.keyFn(u)
+	now := s.now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	grants := s.grants[key]
+	// drop the grants that left the window
+	i := 0
+	for i < len(grants) && !grants[i].After(now.Add(-s.window)) {
+		i++
+	}
+	grants = grants[i:]
+	if len(grants) >= s.limit {
+		s.grants[key] = grants
+		return false
+	}
+	s.grants[key] = append(grants, now)
+	return true
+}
+
+func (s *RateLimitedSpecification) String() string {
+	return fmt.Sprintf("RateLimited(%s, %d per %v)", specString(s.spec), s.limit, s.window)
+}
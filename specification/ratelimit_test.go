@@ -0,0 +1,59 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+// fakeClock is a clock advanced by the tests
+type fakeClock struct {
+	t time.Time
+}
+
+func (c *fakeClock) now() time.Time {
+	return c.t
+}
+
+func TestRateLimited(t *testing.T) {
+	clock := &fakeClock{t: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)}
+	s := RateLimited(NotLocked, 3, time.Minute, userName).WithClock(clock.now)
+	boo, foo := &User{Name: "boo"}, &User{Name: "foo"}
+
+	steps := []struct {
+		name    string
+		advance time.Duration
+		user    *User
+		want    bool
+	}{
+		{"first grant", 0, boo, true},
+		{"second grant", 10 * time.Second, boo, true},
+		{"third grant", 10 * time.Second, boo, true},
+		{"limit+1 within the window", 10 * time.Second, boo, false},
+		{"other key has its own limit", 0, foo, true},
+		{"still within the window of the first grant", 29 * time.Second, boo, false},
+		{"first grant left the window", time.Second, boo, true},
+		{"window full again", 0, boo, false},
+		{"all grants left the window", 2 * time.Minute, boo, true},
+	}
+	for _, step := range steps {
+		clock.t = clock.t.Add(step.advance)
+		if got := s.IsSatisfiedBy(step.user); got != step.want {
+			t.Errorf("%s: IsSatisfiedBy(%s) = %v, want %v", step.name, step.user.Name, got, step.want)
+		}
+	}
+}
+
+func TestRateLimitedInnerFailureNotCounted(t *testing.T) {
+	clock := &fakeClock{t: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)}
+	s := RateLimited(NotLocked, 1, time.Minute, userName).WithClock(clock.now)
+	u := &User{Name: "boo", Locked: true}
+	for i := 0; i < 3; i++ {
+		if s.IsSatisfiedBy(u) {
+			t.Fatalf("locked user granted")
+		}
+	}
+	u.Locked = false
+	if !s.IsSatisfiedBy(u) {
+		t.Errorf("denials of the inner specification used the limit")
+	}
+}
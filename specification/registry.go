@@ -0,0 +1,107 @@
+package main
+
+import (
+	"sync"
+	"time"
+)
+
+// rule of the registry: a built specification or an expression parsed on use
+type rule struct {
+	spec SpecificationUser
+	expr string
+}
+
+// RuleVersion is an entry of the history of a rule
+type RuleVersion struct {
+	Time time.Time
+	// Definition is the String of the specification or the expression of the alias
+	Definition string
+}
+
+// maxRuleHistory is the number of versions kept per rule, the oldest are dropped
+const maxRuleHistory = 100
+
+var (
+	registryMu sync.RWMutex
+	registry   = make(map[string]rule)
+	history    = make(map[string][]RuleVersion)
+)
+
+// DefineRule registers the specification under the name, replacing the previous one
+func DefineRule(name string, spec SpecificationUser) {
+	registryMu.Lock()
+	registry[name] = rule{spec: spec}
+	recordRule(name, specString(spec))
+	registryMu.Unlock()
+}
+
+// DefineAlias registers a Parse expression under the name. The expression is parsed
+// each time the name is used, so it may refer to rules defined later.
+func DefineAlias(name, expr string) {
+	registryMu.Lock()
+	registry[name] = rule{expr: expr}
+	recordRule(name, expr)
+	registryMu.Unlock()
+}
+
+// Rule returns the specification registered under the name,
+// an alias is parsed into its subtree
+func Rule(name string) (SpecificationUser, bool, error) {
+	r, ok := lookupRule(name)
+	if !ok {
+		return nil, false, nil
+	}
+	if r.spec != nil {
+		return r.spec, true, nil
+	}
+	spec, err := parseAlias(name, r.expr, nil)
+	return spec, true, err
+}
+
+func lookupRule(name string) (rule, bool) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	r, ok := registry[name]
+	return r, ok
+}
+
+// recordRule appends the definition to the history of the rule, registryMu must be held
+func recordRule(name, definition string) {
+	versions := append(history[name], RuleVersion{Time: time.Now(), Definition: definition})
+	if len(versions) > maxRuleHistory {
+		versions = versions[len(versions)-maxRuleHistory:]
+	}
+	history[name] = versions
+}
+
+// RuleHistory returns the definitions of the rule from the oldest to the latest
+func RuleHistory(name string) []RuleVersion {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	return append([]RuleVersion(nil), history[name]...)
+}
+
+// SnapshotRegistry captures the rules and their history and returns the function
+// restoring them, for tests registering temporary rules:
+//
+//	defer SnapshotRegistry()()
+//
+// The restore replaces everything registered since the snapshot, so snapshots of
+// concurrent tests would overwrite each other; use it from sequential tests.
+func SnapshotRegistry() func() {
+	registryMu.RLock()
+	rules := make(map[string]rule, len(registry))
+	for name, r := range registry {
+		rules[name] = r
+	}
+	versions := make(map[string][]RuleVersion, len(history))
+	for name, h := range history {
+		versions[name] = append([]RuleVersion(nil), h...)
+	}
+	registryMu.RUnlock()
+	return func() {
+		registryMu.Lock()
+		registry, history = rules, versions
+		registryMu.Unlock()
+	}
+}
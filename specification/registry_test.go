@@ -0,0 +1,77 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestRuleHistory(t *testing.T) {
+	defer SnapshotRegistry()()
+	DefineRule("staff", AnyAdmin)
+	DefineRule("staff", And(AnyAdmin, NotLocked))
+	DefineAlias("staff", "isAdmin")
+
+	want := []string{
+		"Or(Type(ADMIN), Type(SUPER ADMIN))",
+		"And(Or(Type(ADMIN), Type(SUPER ADMIN)), Not(Locked))",
+		"isAdmin",
+	}
+	versions := RuleHistory("staff")
+	if len(versions) != len(want) {
+		t.Fatalf("RuleHistory = %d versions, want %d", len(versions), len(want))
+	}
+	for i, v := range versions {
+		if v.Definition != want[i] {
+			t.Errorf("version %d = %s, want %s", i, v.Definition, want[i])
+		}
+		if i > 0 && v.Time.Before(versions[i-1].Time) {
+			t.Errorf("version %d is older than the previous one", i)
+		}
+	}
+
+	// the returned history is a copy
+	versions[0].Definition = "changed"
+	if RuleHistory("staff")[0].Definition == "changed" {
+		t.Errorf("RuleHistory returned the history of the registry")
+	}
+	if versions := RuleHistory("undefined"); len(versions) != 0 {
+		t.Errorf("RuleHistory(undefined) = %v", versions)
+	}
+}
+
+func TestRuleHistoryCap(t *testing.T) {
+	defer SnapshotRegistry()()
+	for i := 0; i < maxRuleHistory+5; i++ {
+		DefineAlias("rule", fmt.Sprintf("alias%d", i))
+	}
+	versions := RuleHistory("rule")
+	if len(versions) != maxRuleHistory {
+		t.Fatalf("RuleHistory = %d versions, want the cap %d", len(versions), maxRuleHistory)
+	}
+	if first, last := versions[0].Definition, versions[len(versions)-1].Definition; first != "alias5" || last != fmt.Sprintf("alias%d", maxRuleHistory+4) {
+		t.Errorf("RuleHistory kept %s..%s, want the latest versions", first, last)
+	}
+}
+
+func TestSnapshotRegistryRestore(t *testing.T) {
+	defer SnapshotRegistry()()
+	DefineRule("kept", Locked)
+
+	restore := SnapshotRegistry()
+	DefineRule("kept", NotLocked)
+	DefineRule("temporary", IsAdmin)
+	restore()
+
+	if spec, ok, err := Rule("kept"); !ok || err != nil || specString(spec) != "Locked" {
+		t.Errorf("Rule(kept) = %v, %v, %v, want the rule of the snapshot", spec, ok, err)
+	}
+	if _, ok, _ := Rule("temporary"); ok {
+		t.Errorf("Rule(temporary) survived the restore")
+	}
+	if n := len(RuleHistory("kept")); n != 1 {
+		t.Errorf("RuleHistory(kept) = %d versions, want the one of the snapshot", n)
+	}
+	if n := len(RuleHistory("temporary")); n != 0 {
+		t.Errorf("RuleHistory(temporary) = %d versions after the restore", n)
+	}
+}
@@ -0,0 +1,27 @@
+package main
+
+// RelationSpecification is a specification over a pair of users,
+// e.g. a user and their manager
+type RelationSpecification interface {
+	IsSatisfiedByPair(subject, related *User) bool
+}
+
+// RelatedIs: the related user satisfies the specification, a missing (nil)
+// related user does not
+type RelatedIsSpecification struct {
+	spec SpecificationUser
+}
+
+func RelatedIs(spec SpecificationUser) *RelatedIsSpecification {
+	return &RelatedIsSpecification{
+		spec: spec,
+	}
+}
+
+func (s *RelatedIsSpecification) IsSatisfiedByPair(subject, related *User) bool {
+	return related != nil && s.spec.IsSatisfiedBy(related)
+}
+
+func (s *RelatedIsSpecification) String() string {
+	return "RelatedIs(" + specString(s.spec) + ")"
+}
@@ -0,0 +1,28 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestRelatedIs(t *testing.T) {
+	var managerIsAdmin RelationSpecification = RelatedIs(AnyAdmin)
+	subject := &User{Name: "boo"}
+	tests := []struct {
+		name    string
+		related *User
+		want    bool
+	}{
+		{"admin manager", &User{Type: Admin, Name: "foo"}, true},
+		{"super admin manager", &User{Type: SuperAdmin, Name: "foo"}, true},
+		{"personal manager", &User{Type: Personal, Name: "foo"}, false},
+		{"no manager", nil, false},
+	}
+	for _, tt := range tests {
+		if got := managerIsAdmin.IsSatisfiedByPair(subject, tt.related); got != tt.want {
+			t.Errorf("%s: IsSatisfiedByPair = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+	if got, want := RelatedIs(IsAdmin).String(), "RelatedIs(Type(ADMIN))"; got != want {
+		t.Errorf("String = %s, want %s", got, want)
+	}
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// Result is the evaluation tree of a specification for a user
+type Result struct {
+	Spec     SpecificationUser
+	Name     string
+	Ok       bool
+	Children []*Result
+}
+
+// Evaluate evaluates every node of the specification without short-circuit,
+// so the result explains the whole decision. Every node is evaluated once per call
+// and the result of a leaf referenced several times in the tree is computed once.
+// A Composite other than the built-in ones is evaluated as a leaf, without children,
+// since its result cannot be derived from the results of its children.
+func Evaluate(spec SpecificationUser, u *User) *Result {
+	return evaluate(spec, u, make(memo))
+}
+
+// EvaluateSnapshot evaluates the specification against a shallow copy of the user taken
+// at the start, so a mutation made during the evaluation cannot make two leaves see
+// different values (e.g. Locked flipping between two checks). The copy itself is a read
+// of the user: the writers still have to synchronize with it, and reference fields like
+// Metadata are shared.
+func EvaluateSnapshot(spec SpecificationUser, u *User) *Result {
+	snapshot := *u
+	return Evaluate(spec, &snapshot)
+}
+
+// combiner is implemented by the composites whose result is a function of the results
+// of their children in the order of Children, so Evaluate evaluates each child once
+type combiner interface {
+	Composite
+	combine(results []bool) bool
+}
+
+// memo holds the results of the leaves during one evaluation, keyed by the spec pointer
+type memo map[SpecificationUser]bool
+
+// leaf returns the memoized result of the leaf, specifications that are not
+// pointers are not comparable in general and are always evaluated
+func (m memo) leaf(spec SpecificationUser, u *User) bool {
+	if reflect.ValueOf(spec).Kind() != reflect.Ptr {
+		return spec.IsSatisfiedBy(u)
+	}
+	ok, found := m[spec]
+	if !found {
+		ok = spec.IsSatisfiedBy(u)
+		m[spec] = ok
+	}
+	return ok
+}
+
+func evaluate(spec SpecificationUser, u *User, m memo) *Result {
+	r := &Result{
+		Spec: spec,
+		Name: specName(spec),
+	}
+	switch s := spec.(type) {
+	case *AndSpecification:
+		r.Ok = true
+		for _, child := range s.specs {
+			cr := evaluate(child, u, m)
+			r.Ok = r.Ok && cr.Ok
+			r.Children = append(r.Children, cr)
+		}
+	case *OrSpecification:
+		for _, child := range s.specs {
+			cr := evaluate(child, u, m)
+			r.Ok = r.Ok || cr.Ok
+			r.Children = append(r.Children, cr)
+		}
+	case *NotSpecification:
+		cr := evaluate(s.spec, u, m)
+		r.Ok = !cr.Ok
+		r.Children = append(r.Children, cr)
+	case combiner:
+		children := s.Children()
+		results := make([]bool, len(children))
+		for i, child := range children {
+			cr := evaluate(child, u, m)
+			results[i] = cr.Ok
+			r.Children = append(r.Children, cr)
+		}
+		r.Ok = s.combine(results)
+	default:
+		r.Ok = m.leaf(spec, u)
+	}
+	return r
+}
+
+func specName(spec SpecificationUser) string {
+	switch spec.(type) {
+	case *AndSpecification:
+		return "AND"
+	case *OrSpecification:
+		return "OR"
+	case *NotSpecification:
+		return "NOT"
+	case *NoneOfSpecification:
+		return "NONE OF"
+	}
+	if s, ok := spec.(fmt.Stringer); ok {
+		return s.String()
+	}
+	return strings.TrimSuffix(strings.TrimPrefix(fmt.Sprintf("%T", spec), "*main."), "Specification")
+}
+
+// specString returns the String of the specification,
+// or its type and address if it is not a fmt.Stringer
+func specString(spec SpecificationUser) string {
+	if s, ok := spec.(fmt.Stringer); ok {
+		return s.String()
+	}
+	return fmt.Sprintf("%s@%p", specName(spec), spec)
+}
+
+func joinSpecStrings(specs []SpecificationUser) string {
+	parts := make([]string, len(specs))
+	for i, spec := range specs {
+		parts[i] = specString(spec)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// FormatTree returns an indented outline of the evaluation, one node per line
+func FormatTree(r *Result) string {
+	var sb strings.Builder
+	formatTree(&sb, r, 0)
+	return sb.String()
+}
+
+func formatTree(sb *strings.Builder, r *Result, level int) {
+	mark := "✗"
+	if r.Ok {
+		mark = "✓"
+	}
+	fmt.Fprintf(sb, "%s%s %s\n", strings.Repeat("  ", level), mark, r.Name)
+	for _, child := range r.Children {
+		formatTree(sb, child, level+1)
+	}
+}
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+// countingSpec counts its evaluations
+type countingSpec struct {
+	ok    bool
+	calls int `hash:"-"`
+}
+
+func (s *countingSpec) IsSatisfiedBy(u *User) bool {
+	s.calls++
+	return s.ok
+}
+
+func TestEvaluateCompositeChildrenOnce(t *testing.T) {
+	tests := []struct {
+		name  string
+		build func(leaf SpecificationUser) SpecificationUser
+	}{
+		{"NoneOf", func(leaf SpecificationUser) SpecificationUser { return NoneOf(leaf, IsAdmin) }},
+		{"SatisfiedInRange", func(leaf SpecificationUser) SpecificationUser { return SatisfiedInRange(1, 2, leaf, IsAdmin) }},
+		{"OrWeighted", func(leaf SpecificationUser) SpecificationUser {
+			return OrWeighted(1, ConfidenceSpec{leaf, 0.5}, ConfidenceSpec{IsAdmin, 0.5})
+		}},
+		{"Agree", func(leaf SpecificationUser) SpecificationUser { return Agree(leaf, IsAdmin) }},
+		{"Majority", func(leaf SpecificationUser) SpecificationUser { return Majority(leaf, IsAdmin, Locked) }},
+		{"Policy", func(leaf SpecificationUser) SpecificationUser {
+			return Policy(1, []WeightedSpec{{leaf, 1}}, []SpecificationUser{Locked})
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			leaf := &countingSpec{ok: true}
+			spec := tt.build(leaf)
+			u := &User{Type: Admin}
+			r := Evaluate(spec, u)
+			if leaf.calls != 1 {
+				t.Errorf("leaf evaluated %d times, want 1", leaf.calls)
+			}
+			if want := spec.IsSatisfiedBy(u); r.Ok != want {
+				t.Errorf("Evaluate = %v, IsSatisfiedBy = %v", r.Ok, want)
+			}
+		})
+	}
+}
+
+func TestEvaluateRateLimitedUnderMajority(t *testing.T) {
+	spec := Majority(RateLimited(IsAdmin, 2, time.Minute, func(u *User) string { return u.Name }))
+	u := &User{Type: Admin}
+	for i := 0; i < 2; i++ {
+		if r := Evaluate(spec, u); !r.Ok {
+			t.Fatalf("Evaluate #%d denied, want granted", i+1)
+		}
+	}
+}
+
+func TestEvaluateSharedLeafOnce(t *testing.T) {
+	leaf := &countingSpec{ok: true}
+	spec := And(leaf, Not(Not(leaf)), Or(IsAdmin, leaf))
+	u := &User{Type: Personal}
+	if r := Evaluate(spec, u); !r.Ok {
+		t.Errorf("Evaluate = false, want true")
+	}
+	if leaf.calls != 1 {
+		t.Errorf("shared leaf evaluated %d times in one call, want 1", leaf.calls)
+	}
+	// the memo lives for one call
+	Evaluate(spec, u)
+	if leaf.calls != 2 {
+		t.Errorf("shared leaf evaluated %d times in two calls, want 2", leaf.calls)
+	}
+}
+
+// expensiveSpec burns CPU on every evaluation, like a leaf calling a slow check
+type expensiveSpec struct {
+	rounds int
+}
+
+func (s *expensiveSpec) IsSatisfiedBy(u *User) bool {
+	h := uint32(2166136261)
+	for i := 0; i < s.rounds; i++ {
+		for j := 0; j < len(u.Name); j++ {
+			h = (h ^ uint32(u.Name[j])) * 16777619
+		}
+	}
+	return h%2 == 0
+}
+
+func BenchmarkEvaluateSharedLeaf(b *testing.B) {
+	u := &User{Type: Personal, Name: "alexander"}
+	b.Run("shared", func(b *testing.B) {
+		leaf := &expensiveSpec{rounds: 1000}
+		spec := Or(And(leaf, NotLocked), Not(leaf), And(IsAdmin, leaf))
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			Evaluate(spec, u)
+		}
+	})
+	b.Run("distinct", func(b *testing.B) {
+		spec := Or(And(&expensiveSpec{rounds: 1000}, NotLocked), Not(&expensiveSpec{rounds: 1000}),
+			And(IsAdmin, &expensiveSpec{rounds: 1000}))
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			Evaluate(spec, u)
+		}
+	})
+}
+
+func TestFormatTreeGolden(t *testing.T) {
+	spec := And(Not(AnyAdmin), NotLocked, Or(IsNameShort4, Name("alexander")))
+	u := &User{Type: Admin, Name: "alexander"}
+	want := `✗ AND
+  ✗ NOT
+    ✓ OR
+      ✓ Type(ADMIN)
+      ✗ Type(SUPER ADMIN)
+  ✓ NOT
+    ✗ Locked
+  ✓ OR
+    ✗ NameShort(4)
+    ✓ Name(alexander)
+`
+	if got := FormatTree(Evaluate(spec, u)); got != want {
+		t.Errorf("FormatTree =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestEvaluateSnapshotConsistent(t *testing.T) {
+	u := &User{Name: "boo"}
+	// the first leaf lets another goroutine lock the user and waits for it
+	mutate := SpecFunc(func(*User) bool {
+		done := make(chan struct{})
+		go func() {
+			u.Locked = true
+			close(done)
+		}()
+		<-done
+		return true
+	})
+	// the second check is a SpecFunc, the memo would reuse the result of a shared leaf
+	unlocked := SpecFunc(func(u *User) bool { return !u.Locked })
+	spec := And(NotLocked, mutate, unlocked)
+
+	r := EvaluateSnapshot(spec, u)
+	if !u.Locked {
+		t.Fatalf("the user was not mutated")
+	}
+	if !r.Ok || !r.Children[0].Ok || !r.Children[2].Ok {
+		t.Errorf("the leaves saw different values of Locked:\n%s", FormatTree(r))
+	}
+
+	// without the snapshot the second check sees the mutation
+	u.Locked = false
+	if r := Evaluate(spec, u); r.Children[0].Ok == r.Children[2].Ok {
+		t.Errorf("the mutation was not visible to Evaluate:\n%s", FormatTree(r))
+	}
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"fmt"
+	"time"
+)
+
+// RiskWeights are the points added to the risk score by each signal
+type RiskWeights struct {
+	// Locked is added for a currently locked user
+	Locked int
+	// PreviouslyLocked is added for an unlocked user with a LockedAt time
+	PreviouslyLocked int
+	// Placeholder is added for a placeholder name like "user" or "test"
+	Placeholder int
+	// NewAccount is added when the account is younger than NewAccountAge
+	NewAccount    int
+	NewAccountAge time.Duration
+}
+
+// DefaultRiskWeights are used by RiskScore
+var DefaultRiskWeights = RiskWeights{
+	Locked:           50,
+	PreviouslyLocked: 20,
+	Placeholder:      30,
+	NewAccount:       30,
+	NewAccountAge:    7 * 24 * time.Hour,
+}
+
+// Score adds up the weights of the signals of the user at the time now
+func (w RiskWeights) Score(u *User, now time.Time) int {
+	score := 0
+	switch {
+	case u.Locked:
+		score += w.Locked
+	case !u.LockedAt.IsZero():
+		score += w.PreviouslyLocked
+	}
+	if (&PlaceholderNameSpecification{}).IsSatisfiedBy(u) {
+		score += w.Placeholder
+	}
+	if now.Sub(u.CreatedAt) < w.NewAccountAge {
+		score += w.NewAccount
+	}
+	return score
+}
+
+// RiskScore is the score of the user with DefaultRiskWeights, the higher the riskier
+func RiskScore(u *User) int {
+	return DefaultRiskWeights.Score(u, time.Now())
+}
+
+// RiskBelow: the risk score of the user is less than the threshold
+type RiskBelowSpecification struct {
+	threshold int
+	score     func(*User) int
+}
+
+func RiskBelow(threshold int) *RiskBelowSpecification {
+	return &RiskBelowSpecification{
+		threshold: threshold,
+		score:     RiskScore,
+	}
+}
+
+// WithScore replaces the scoring function, e.g. with the Score of tuned weights
+func (s *RiskBelowSpecification) WithScore(score func(*User) int) *RiskBelowSpecification {
+	s.score = score
+	return s
+}
+
+func (s *RiskBelowSpecification) IsSatisfiedBy(u *User) bool {
+	return s.score(u) < s.threshold
+}
+
+func (s *RiskBelowSpecification) String() string {
+	return fmt.Sprintf("RiskBelow(%d)", s.threshold)
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRiskWeightsScore(t *testing.T) {
+	now := time.Date(2026, 1, 10, 0, 0, 0, 0, time.UTC)
+	old := now.Add(-30 * 24 * time.Hour)
+	tests := []struct {
+		name string
+		user *User
+		want int
+	}{
+		{"old account, real name", &User{Name: "alexander", CreatedAt: old}, 0},
+		{"locked", &User{Name: "alexander", CreatedAt: old, Locked: true, LockedAt: old}, 50},
+		{"previously locked", &User{Name: "alexander", CreatedAt: old, LockedAt: old}, 20},
+		{"placeholder name", &User{Name: " Test ", CreatedAt: old}, 30},
+		{"new account", &User{Name: "alexander", CreatedAt: now.Add(-time.Hour)}, 30},
+		{"fresh placeholder account", &User{Name: "test", CreatedAt: now}, 60},
+		{"everything", &User{Name: "user", CreatedAt: now, Locked: true}, 110},
+	}
+	for _, tt := range tests {
+		if got := DefaultRiskWeights.Score(tt.user, now); got != tt.want {
+			t.Errorf("%s: Score = %d, want %d", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestRiskBelow(t *testing.T) {
+	old := time.Now().Add(-30 * 24 * time.Hour)
+	RunSpecTests(t, RiskBelow(50), []SpecCase{
+		{Name: "fresh placeholder account", User: &User{Name: "test", CreatedAt: time.Now()}, Expected: false},
+		{Name: "established account", User: &User{Name: "alexander", CreatedAt: old}, Expected: true},
+		{Name: "locked", User: &User{Name: "alexander", CreatedAt: old, Locked: true}, Expected: false},
+	})
+
+	// tuned weights ignore the placeholder name
+	tuned := DefaultRiskWeights
+	tuned.Placeholder = 0
+	s := RiskBelow(50).WithScore(func(u *User) int { return tuned.Score(u, time.Now()) })
+	RunSpecTests(t, s, []SpecCase{
+		{Name: "fresh placeholder account with tuned weights", User: &User{Name: "test", CreatedAt: time.Now()}, Expected: true},
+	})
+}
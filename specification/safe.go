@@ -0,0 +1,40 @@
+package main
+
+// Safe recovers from a panic of the wrapped specification, the user then does not
+// satisfy it and the recovered value is reported to the OnPanic callback
+type SafeSpecification struct {
+	spec    SpecificationUser
+	onPanic func(recovered interface{})
+}
+
+func Safe(spec SpecificationUser) *SafeSpecification {
+	return &SafeSpecification{
+		spec: spec,
+	}
+}
+
+// OnPanic sets the callback receiving the recovered value
+func (s *SafeSpecification) OnPanic(fn func(recovered interface{})) *SafeSpecification {
+	s.onPanic = fn
+	return s
+}
+
+func (s *SafeSpecification) IsSatisfiedBy(u *User) (ok bool) {
+	defer func() {
+		if r := recover(); r != nil {
+			ok = false
+			if s.onPanic != nil {
+				s.onPanic(r)
+			}
+		}
+	}()
+	return s.spec.IsSatisfiedBy(u)
+}
+
+func (s *SafeSpecification) Inner() SpecificationUser {
+	return s.spec
+}
+
+func (s *SafeSpecification) String() string {
+	return "Safe(" + specString(s.spec) + ")"
+}
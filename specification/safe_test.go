@@ -0,0 +1,34 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestSafeRecoversPanic(t *testing.T) {
+	var recovered []interface{}
+	panicking := SpecFunc(func(u *User) bool {
+		if u.Name == "" {
+			panic("empty name")
+		}
+		return true
+	})
+	s := Safe(panicking).OnPanic(func(r interface{}) { recovered = append(recovered, r) })
+
+	if s.IsSatisfiedBy(&User{}) {
+		t.Errorf("IsSatisfiedBy of a panicking specification = true")
+	}
+	if len(recovered) != 1 || recovered[0] != "empty name" {
+		t.Errorf("OnPanic received %v, want [empty name]", recovered)
+	}
+	if !s.IsSatisfiedBy(&User{Name: "boo"}) || len(recovered) != 1 {
+		t.Errorf("a specification that does not panic is not passed through")
+	}
+	// without a callback the panic is only recovered
+	if Safe(panicking).IsSatisfiedBy(&User{}) {
+		t.Errorf("IsSatisfiedBy without OnPanic = true")
+	}
+	// nested in a tree, the rest of the tree is evaluated
+	if !Or(Safe(panicking), IsPersonal).IsSatisfiedBy(&User{}) {
+		t.Errorf("the panic stopped the evaluation of the tree")
+	}
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// SetSpecification is a specification over a set of users, e.g. the members of a team
+type SetSpecification interface {
+	IsSatisfiedBySet(users []*User) bool
+}
+
+// ExistsMatching: at least one user satisfies the specification, an empty set does not
+type ExistsSpecification struct {
+	spec SpecificationUser
+}
+
+func ExistsMatching(spec SpecificationUser) *ExistsSpecification {
+	return &ExistsSpecification{
+		spec: spec,
+	}
+}
+
+func (s *ExistsSpecification) IsSatisfiedBySet(users []*User) bool {
+	for _, u := range users {
+		if s.spec.IsSatisfiedBy(u) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *ExistsSpecification) String() string {
+	return "ExistsMatching(" + specString(s.spec) + ")"
+}
+
+// AtMostN: no more than n users satisfy the specification
+type AtMostSpecification struct {
+	n    int
+	spec SpecificationUser
+}
+
+func AtMostN(n int, spec SpecificationUser) *AtMostSpecification {
+	return &AtMostSpecification{
+		n:    n,
+		spec: spec,
+	}
+}
+
+func (s *AtMostSpecification) IsSatisfiedBySet(users []*User) bool {
+	count := 0
+	for _, u := range users {
+		if s.spec.IsSatisfiedBy(u) {
+			count++
+			if count > s.n {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func (s *AtMostSpecification) String() string {
+	return fmt.Sprintf("AtMostN(%d, %s)", s.n, specString(s.spec))
+}
+
+// Percentile builds the specification passing the users whose extracted value is at
+// or above the value of the top topPercent% of the cohort, e.g. the 10% oldest accounts.
+// The threshold is computed once from the cohort: it is the value of the ceil(n*p/100)-th
+// largest value, so ties with it pass as well. An empty cohort or topPercent <= 0 passes nobody.
+func Percentile(users []*User, extract func(*User) float64, topPercent float64) SpecificationUser {
+	values := make([]float64, len(users))
+	for i, u := range users {
+		values[i] = extract(u)
+	}
+	sort.Sort(sort.Reverse(sort.Float64Slice(values)))
+	threshold := math.Inf(1)
+	if k := int(math.Ceil(float64(len(values)) * topPercent / 100)); k > 0 {
+		if k > len(values) {
+			k = len(values)
+		}
+		threshold = values[k-1]
+	}
+	return &PercentileSpecification{
+		extract:   extract,
+		threshold: threshold,
+		percent:   topPercent,
+	}
+}
+
+// PercentileSpecification is built by Percentile
+type PercentileSpecification struct {
+	extract   func(*User) float64
+	threshold float64
+	percent   float64
+}
+
+// Threshold returns the value computed from the cohort
+func (s *PercentileSpecification) Threshold() float64 {
+	return s.threshold
+}
+
+func (s *PercentileSpecification) IsSatisfiedBy(u *User) bool {
+	return s.extract(u) >= s.threshold
+}
+
+func (s *PercentileSpecification) String() string {
+	return fmt.Sprintf("Percentile(top %g%%, >= %g)", s.percent, s.threshold)
+}
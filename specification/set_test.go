@@ -0,0 +1,71 @@
+package main
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestExistsMatchingAndAtMostN(t *testing.T) {
+	admin, super, personal := &User{Type: Admin}, &User{Type: SuperAdmin}, &User{Type: Personal}
+	tests := []struct {
+		name           string
+		users          []*User
+		exists, atMost bool
+	}{
+		{"empty set", nil, false, true},
+		{"single match", []*User{personal, super}, true, true},
+		{"no match", []*User{personal, admin}, false, true},
+		{"two matches", []*User{super, personal, super}, true, true},
+		{"three matches", []*User{super, super, admin, super}, true, false},
+	}
+	exists := ExistsMatching(IsSuperAdmin)
+	atMost := AtMostN(2, IsSuperAdmin)
+	for _, tt := range tests {
+		if got := exists.IsSatisfiedBySet(tt.users); got != tt.exists {
+			t.Errorf("%s: ExistsMatching = %v, want %v", tt.name, got, tt.exists)
+		}
+		if got := atMost.IsSatisfiedBySet(tt.users); got != tt.atMost {
+			t.Errorf("%s: AtMostN(2) = %v, want %v", tt.name, got, tt.atMost)
+		}
+	}
+}
+
+func TestPercentile(t *testing.T) {
+	// the ages 1..100
+	users := make([]*User, 100)
+	for i := range users {
+		users[i] = &User{CreatedAt: time.Unix(int64(i+1), 0)}
+	}
+	age := func(u *User) float64 { return float64(u.CreatedAt.Unix()) }
+	tests := []struct {
+		percent   float64
+		threshold float64
+		passed    int
+	}{
+		{10, 91, 10},
+		{25, 76, 25},
+		{0.5, 100, 1},
+		{100, 1, 100},
+		{150, 1, 100},
+		{0, math.Inf(1), 0},
+	}
+	for _, tt := range tests {
+		s := Percentile(users, age, tt.percent).(*PercentileSpecification)
+		if s.Threshold() != tt.threshold {
+			t.Errorf("Percentile(%g).Threshold = %g, want %g", tt.percent, s.Threshold(), tt.threshold)
+		}
+		if passed := len(Filter(users, s)); passed != tt.passed {
+			t.Errorf("Percentile(%g) passes %d users, want %d", tt.percent, passed, tt.passed)
+		}
+	}
+
+	// the ties with the threshold pass
+	tied := []*User{{CreatedAt: time.Unix(5, 0)}, {CreatedAt: time.Unix(5, 0)}, {CreatedAt: time.Unix(1, 0)}}
+	if passed := len(Filter(tied, Percentile(tied, age, 10))); passed != 2 {
+		t.Errorf("Percentile with a tie passes %d users, want 2", passed)
+	}
+	if Percentile(nil, age, 50).IsSatisfiedBy(users[99]) {
+		t.Errorf("Percentile of an empty cohort passes a user")
+	}
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"fmt"
+	"hash/fnv"
+	"sort"
+)
+
+// FieldReader is implemented by specifications declaring the fields of User they read,
+// e.g. []string{"Name", "Locked"}, for DecisionSignature. A Wrapper or Composite
+// declares the fields it reads besides its children, e.g. the key of Sampled.
+type FieldReader interface {
+	Fields() []string
+}
+
+// userFields are the values of the fields of User by name
+var userFields = map[string]func(*User) interface{}{
+	"Type":          func(u *User) interface{} { return u.Type },
+	"Name":          func(u *User) interface{} { return u.Name },
+	"Locked":        func(u *User) interface{} { return u.Locked },
+	"LockReason":    func(u *User) interface{} { return u.LockReason },
+	"LockedAt":      func(u *User) interface{} { return u.LockedAt.UnixNano() },
+	"CreatedAt":     func(u *User) interface{} { return u.CreatedAt.UnixNano() },
+	"UpdatedAt":     func(u *User) interface{} { return u.UpdatedAt.UnixNano() },
+	"Metadata":      func(u *User) interface{} { return u.Metadata },
+	"Phone":         func(u *User) interface{} { return u.Phone },
+	"Email":         func(u *User) interface{} { return u.Email },
+	"Country":       func(u *User) interface{} { return u.Country },
+	"DeleteAfter":   func(u *User) interface{} { return u.DeleteAfter.UnixNano() },
+	"NameChangedAt": func(u *User) interface{} { return u.NameChangedAt.UnixNano() },
+}
+
+// identityField is the pseudo-field of the specifications deciding by the user pointer
+// (IsOneOf, NameUniqueNormalized), its value is the address of the user
+const identityField = "(identity)"
+
+// DecisionSignature returns a short stable key of the decision of the specification
+// for the user, usable as an ETag: it combines the Hash of the specification with the
+// values of the fields the specification reads, so users differing only in the other
+// fields share the signature.
+//
+// User is a plain struct and its reads cannot be intercepted, so the fields are
+// collected from the tree: the built-in leaves are known, other specifications may
+// implement FieldReader, and the other leaves (SpecFunc, ByType, Expr, ...) are assumed
+// to read every field. The specifications deciding by identity add the address of the
+// user, so their signatures are stable within a process only, like their Hash.
+// The clock of the time-based specifications is not part of the signature.
+func DecisionSignature(spec SpecificationUser, u *User) string {
+	fields := make(map[string]struct{})
+	collectFields(spec, fields)
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	h := fnv.New64a()
+	fmt.Fprintf(h, "%x", Hash(spec))
+	for _, name := range names {
+		// %v prints the maps of Metadata with sorted keys
+		if name == identityField {
+			fmt.Fprintf(h, "|%s=%p", name, u)
+			continue
+		}
+		fmt.Fprintf(h, "|%s=%v", name, userFields[name](u))
+	}
+	return fmt.Sprintf("%016x", h.Sum64())
+}
+
+// collectFields adds the fields read by the nodes of the tree
+func collectFields(spec SpecificationUser, fields map[string]struct{}) {
+	var children []SpecificationUser
+	switch s := spec.(type) {
+	case Composite:
+		children = s.Children()
+	case Wrapper:
+		children = []SpecificationUser{s.Inner()}
+	default:
+		for _, name := range readFields(spec) {
+			fields[name] = struct{}{}
+		}
+		return
+	}
+	if r, ok := spec.(FieldReader); ok {
+		for _, name := range r.Fields() {
+			fields[name] = struct{}{}
+		}
+	}
+	for _, child := range children {
+		collectFields(child, fields)
+	}
+}
+
+// readFields returns the fields of User read by the leaf
+func readFields(leaf SpecificationUser) []string {
+	switch s := leaf.(type) {
+	case FieldReader:
+		return s.Fields()
+	case *TypeSpecification, *TypeInSpecification:
+		return []string{"Type"}
+	case *NameLengthSpecification, *NameSpecification, *PlaceholderNameSpecification,
+		*NameNotConfusableSpecification, *NameSimilarSpecification, *NameNotReservedSpecification,
+		*NameAnagramSpecification, *NameTitleCaseSpecification, *NameTrimmedSpecification,
+		*NameWordCountSpecification, *NameNoRepeatsSpecification,
+		*InitialsSpecification, *NameMatchSpecification, *NameEntropySpecification,
+		*NameSoundsLikeSpecification, *NameBetweenSpecification, *NameInBloomSpecification,
+		*NameNotATypeSpecification, *UsernamePolicySpecification:
+		return []string{"Name"}
+	case *NameUniqueNormalizedSpecification:
+		return []string{"Name", identityField}
+	case *IdentitySpecification:
+		return []string{identityField}
+	case *LockedSpecification:
+		return []string{"Locked"}
+	case *LockReasonSpecification:
+		return []string{"Locked", "LockReason"}
+	case *LockedDurationSpecification:
+		return []string{"Locked", "LockedAt"}
+	case *ConsistentLockSpecification:
+		return []string{"Locked", "LockReason"}
+	case *SameDaySpecification, *AccountAgeSpecification:
+		return []string{"CreatedAt"}
+	case *UpdatedWithinSpecification:
+		return []string{"UpdatedAt"}
+	case *NameChangedWithinSpecification:
+		return []string{"NameChangedAt"}
+	case *ValidPhoneSpecification, *PhoneCountrySpecification:
+		return []string{"Phone"}
+	case *EmailLocalSpecification:
+		return []string{"Email"}
+	case *CountrySpecification:
+		return []string{"Country"}
+	case *MetaEqualsSpecification:
+		return []string{"Metadata"}
+	case *DeletionSpecification:
+		return []string{"DeleteAfter"}
+	}
+	return allUserFields()
+}
+
+func allUserFields() []string {
+	all := make([]string, 0, len(userFields))
+	for name := range userFields {
+		all = append(all, name)
+	}
+	return all
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDecisionSignature(t *testing.T) {
+	spec := And(NotLocked, Not(IsNameShort4))
+	base := User{Type: Personal, Name: "alexander", Email: "a@example.com", CreatedAt: time.Unix(100, 0)}
+	tests := []struct {
+		name   string
+		change func(*User)
+		same   bool
+	}{
+		{"unused Type", func(u *User) { u.Type = Admin }, true},
+		{"unused Email", func(u *User) { u.Email = "b@example.com" }, true},
+		{"unused CreatedAt", func(u *User) { u.CreatedAt = time.Unix(200, 0) }, true},
+		{"used Name", func(u *User) { u.Name = "alexandra" }, false},
+		{"used Locked", func(u *User) { u.Locked = true }, false},
+	}
+	want := DecisionSignature(spec, &base)
+	for _, tt := range tests {
+		u := base
+		tt.change(&u)
+		if got := DecisionSignature(spec, &u); (got == want) != tt.same {
+			t.Errorf("%s: signature %s, base %s, want same = %v", tt.name, got, want, tt.same)
+		}
+	}
+	if len(want) != 16 {
+		t.Errorf("signature %q is not 16 hex digits", want)
+	}
+	if DecisionSignature(spec, &base) != want {
+		t.Errorf("signature is not stable")
+	}
+	if DecisionSignature(And(Locked, Not(IsNameShort4)), &base) == want {
+		t.Errorf("different specifications share the signature")
+	}
+}
+
+// nameReader is a leaf declaring it reads the name only
+type nameReader struct{}
+
+func (nameReader) IsSatisfiedBy(u *User) bool { return u.Name != "" }
+func (nameReader) Fields() []string           { return []string{"Name"} }
+
+func TestDecisionSignatureFieldReader(t *testing.T) {
+	base := User{Name: "boo"}
+	locked := base
+	locked.Locked = true
+	if DecisionSignature(nameReader{}, &base) != DecisionSignature(nameReader{}, &locked) {
+		t.Errorf("a FieldReader leaf depends on a field it does not declare")
+	}
+	// a leaf that does not declare its fields depends on all of them
+	leaf := SpecFunc(func(u *User) bool { return u.Name != "" })
+	if DecisionSignature(leaf, &base) == DecisionSignature(leaf, &locked) {
+		t.Errorf("an unknown leaf does not depend on every field")
+	}
+}
+
+func TestDecisionSignatureIdentity(t *testing.T) {
+	boo := &User{Name: "boo"}
+	twin := &User{Name: "boo"}
+	for _, spec := range []SpecificationUser{IsOneOf(boo), NameUniqueNormalized([]*User{boo})} {
+		if DecisionSignature(spec, boo) == DecisionSignature(spec, twin) {
+			t.Errorf("%s: equal users of different decisions share the signature", specString(spec))
+		}
+		if DecisionSignature(spec, boo) != DecisionSignature(spec, boo) {
+			t.Errorf("%s: signature of the same user is not stable", specString(spec))
+		}
+	}
+}
+
+func TestDecisionSignatureConsistentLock(t *testing.T) {
+	base := User{Locked: true, LockReason: "fraud", LockedAt: time.Unix(100, 0)}
+	later := base
+	later.LockedAt = time.Unix(200, 0)
+	if DecisionSignature(ConsistentLockState(), &base) != DecisionSignature(ConsistentLockState(), &later) {
+		t.Errorf("ConsistentLockState depends on LockedAt it does not read")
+	}
+	unlocked := base
+	unlocked.Locked = false
+	if DecisionSignature(ConsistentLockState(), &base) == DecisionSignature(ConsistentLockState(), &unlocked) {
+		t.Errorf("ConsistentLockState does not depend on Locked")
+	}
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+)
+
+// SpecCase is a case of RunSpecTests: the user and the expected IsSatisfiedBy.
+// Name names the subtest, by default it is made of the user fields.
+type SpecCase struct {
+	Name     string
+	User     *User
+	Expected bool
+}
+
+// RunSpecTests runs a subtest per case checking IsSatisfiedBy of the specification,
+// a failure reports the Explain reasons of the user
+func RunSpecTests(t *testing.T, spec SpecificationUser, cases []SpecCase) {
+	t.Helper()
+	for _, c := range cases {
+		c := c
+		name := specCaseName(c)
+		t.Run(name, func(t *testing.T) {
+			if got := spec.IsSatisfiedBy(c.User); got != c.Expected {
+				t.Errorf("%s: IsSatisfiedBy(%s) = %v, want %v; reasons: %q",
+					specString(spec), name, got, c.Expected, Explain(spec, c.User))
+			}
+		})
+	}
+}
+
+// specCaseName is the name of the case or the type, name and lock of its user
+func specCaseName(c SpecCase) string {
+	if c.Name != "" {
+		return c.Name
+	}
+	if c.User == nil {
+		return "nil user"
+	}
+	name := fmt.Sprintf("%s %q", c.User.Type, c.User.Name)
+	if c.User.Locked {
+		name += " locked"
+	}
+	return name
+}
+
+func TestValidNameNotAdmin(t *testing.T) {
+	RunSpecTests(t, ValidNameNotAdmin, []SpecCase{
+		{User: &User{Type: Personal, Name: "alexander"}, Expected: true},
+		{User: &User{Type: Personal, Name: "alex"}, Expected: false},
+		{User: &User{Type: Personal, Name: "alexander", Locked: true}, Expected: false},
+		{User: &User{Type: Admin, Name: "alexander"}, Expected: false},
+		{User: &User{Type: SuperAdmin, Name: "alexander"}, Expected: false},
+		{Name: "empty name", User: &User{Type: Personal}, Expected: false},
+	})
+}
+
+func TestAnyAdmin(t *testing.T) {
+	RunSpecTests(t, AnyAdmin, []SpecCase{
+		{User: &User{Type: Personal, Name: "boo"}, Expected: false},
+		{User: &User{Type: Admin, Name: "boo"}, Expected: true},
+		{User: &User{Type: SuperAdmin, Name: "boo"}, Expected: true},
+		{User: &User{Type: Admin, Name: "boo", Locked: true}, Expected: true},
+	})
+}
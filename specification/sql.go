@@ -0,0 +1,97 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ToSQL translates the specification into a WHERE condition with placeholders and its arguments.
+// Negations are pushed down to the leaves, so Not(Not(x)) is x and negated leaves
+// use the SQL operators <>, NOT IN, etc. instead of NOT (...).
+func ToSQL(spec SpecificationUser) (string, []interface{}, error) {
+	var args []interface{}
+	where, err := toSQL(spec, false, &args)
+	if err != nil {
+		return "", nil, err
+	}
+	return where, args, nil
+}
+
+func toSQL(spec SpecificationUser, neg bool, args *[]interface{}) (string, error) {
+	switch s := spec.(type) {
+	case *NotSpecification:
+		return toSQL(s.spec, !neg, args)
+	case *NotIfSpecification:
+		return toSQL(s.spec, neg != s.negate, args)
+	case *AndSpecification:
+		// De Morgan: NOT (a AND b) = NOT a OR NOT b
+		if neg {
+			return joinSQL(s.specs, " OR ", "1 = 0", neg, args)
+		}
+		return joinSQL(s.specs, " AND ", "1 = 1", neg, args)
+	case *OrSpecification:
+		if neg {
+			return joinSQL(s.specs, " AND ", "1 = 1", neg, args)
+		}
+		return joinSQL(s.specs, " OR ", "1 = 0", neg, args)
+	case *NoneOfSpecification:
+		// NoneOf(a, b) = NOT a AND NOT b
+		if neg {
+			return joinSQL(s.specs, " OR ", "1 = 0", !neg, args)
+		}
+		return joinSQL(s.specs, " AND ", "1 = 1", !neg, args)
+	case *TypeSpecification:
+		*args = append(*args, int(s.typ))
+		return "type " + sqlOp(neg, "=", "<>") + " ?", nil
+	case *TypeInSpecification:
+		types := make([]int, 0, len(s.types))
+		for typ := range s.types {
+			types = append(types, int(typ))
+		}
+		if len(types) == 0 {
+			return sqlOp(neg, "1 = 0", "1 = 1"), nil
+		}
+		sort.Ints(types)
+		for _, typ := range types {
+			*args = append(*args, typ)
+		}
+		placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(types)), ", ")
+		return "type " + sqlOp(neg, "IN", "NOT IN") + " (" + placeholders + ")", nil
+	case *NameSpecification:
+		*args = append(*args, s.name)
+		return "lower(name) " + sqlOp(neg, "=", "<>") + " ?", nil
+	case *NameLengthSpecification:
+		*args = append(*args, s.l)
+		return "length(name) " + sqlOp(neg, "<=", ">") + " ?", nil
+	case *LockedSpecification:
+		*args = append(*args, !neg)
+		return "locked = ?", nil
+	}
+	return "", fmt.Errorf("ToSQL: unsupported specification %T", spec)
+}
+
+func joinSQL(specs []SpecificationUser, sep, empty string, neg bool, args *[]interface{}) (string, error) {
+	if len(specs) == 0 {
+		return empty, nil
+	}
+	parts := make([]string, 0, len(specs))
+	for _, spec := range specs {
+		part, err := toSQL(spec, neg, args)
+		if err != nil {
+			return "", err
+		}
+		if len(specs) > 1 && (strings.Contains(part, " AND ") || strings.Contains(part, " OR ")) {
+			part = "(" + part + ")"
+		}
+		parts = append(parts, part)
+	}
+	return strings.Join(parts, sep), nil
+}
+
+func sqlOp(neg bool, op, negOp string) string {
+	if neg {
+		return negOp
+	}
+	return op
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestToSQL(t *testing.T) {
+	tests := []struct {
+		name  string
+		spec  SpecificationUser
+		where string
+		args  []interface{}
+	}{
+		{"Not(Type)", Not(IsAdmin), "type <> ?", []interface{}{int(Admin)}},
+		{"Not(Name)", Not(Name("Boo")), "lower(name) <> ?", []interface{}{"boo"}},
+		{"Not(Not(x))", Not(Not(IsAdmin)), "type = ?", []interface{}{int(Admin)}},
+		{"Not(TypeIn)", Not(TypeIn(SuperAdmin, Admin)), "type NOT IN (?, ?)", []interface{}{int(Admin), int(SuperAdmin)}},
+		{"Not(NameShort)", Not(IsNameShort4), "length(name) > ?", []interface{}{4}},
+		{"Not(Locked)", NotLocked, "locked = ?", []interface{}{false}},
+		{"Not(And)", Not(And(IsAdmin, Locked)), "type <> ? OR locked = ?", []interface{}{int(Admin), false}},
+		{"Not(Or)", Not(Or(IsAdmin, Locked)), "type <> ? AND locked = ?", []interface{}{int(Admin), false}},
+		{"NoneOf", NoneOf(IsAdmin, Locked), "type <> ? AND locked = ?", []interface{}{int(Admin), false}},
+		{"nested", And(Or(IsAdmin, IsSuperAdmin), NotLocked), "(type = ? OR type = ?) AND locked = ?",
+			[]interface{}{int(Admin), int(SuperAdmin), false}},
+		{"empty And", And(), "1 = 1", nil},
+		{"Not(empty Or)", Not(Or()), "1 = 1", nil},
+		{"empty TypeIn", TypeIn(), "1 = 0", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			where, args, err := ToSQL(tt.spec)
+			if err != nil {
+				t.Fatalf("ToSQL: %v", err)
+			}
+			if where != tt.where {
+				t.Errorf("where = %q, want %q", where, tt.where)
+			}
+			if !reflect.DeepEqual(args, tt.args) {
+				t.Errorf("args = %v, want %v", args, tt.args)
+			}
+		})
+	}
+}
+
+func TestToSQLUnsupported(t *testing.T) {
+	_, _, err := ToSQL(And(IsAdmin, PoolCached(IsAdmin, userName)))
+	if err == nil || err.Error() != "ToSQL: unsupported specification *main.PoolCachedSpecification" {
+		t.Errorf("ToSQL error = %v", err)
+	}
+}
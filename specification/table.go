@@ -0,0 +1,61 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// DecisionRow is a row of a decision table, an empty cell means "any"
+type DecisionRow struct {
+	// Type is a user type name accepted by ParseUserType
+	Type string
+	// Locked is "true" or "false"
+	Locked string
+	// NamePattern is a regular expression the name must match
+	NamePattern string
+}
+
+// FromTable builds the specification of a decision table: the conditions of a row
+// are And-ed and the rows are Or-ed. A row of empty cells matches everybody,
+// an empty table matches nobody.
+func FromTable(rows []DecisionRow) (SpecificationUser, error) {
+	specs := make([]SpecificationUser, 0, len(rows))
+	for i, row := range rows {
+		spec, err := row.spec()
+		if err != nil {
+			return nil, fmt.Errorf("decision table: row %d: %w", i+1, err)
+		}
+		specs = append(specs, spec)
+	}
+	return Or(specs...), nil
+}
+
+func (row DecisionRow) spec() (SpecificationUser, error) {
+	var conditions []SpecificationUser
+	if row.Type != "" {
+		typ, err := ParseUserType(row.Type)
+		if err != nil {
+			return nil, err
+		}
+		conditions = append(conditions, &TypeSpecification{typ: typ})
+	}
+	if row.Locked != "" {
+		locked, err := strconv.ParseBool(row.Locked)
+		if err != nil {
+			return nil, fmt.Errorf("invalid locked %q", row.Locked)
+		}
+		if locked {
+			conditions = append(conditions, Locked)
+		} else {
+			conditions = append(conditions, NotLocked)
+		}
+	}
+	if row.NamePattern != "" {
+		name, err := NameMatches(row.NamePattern)
+		if err != nil {
+			return nil, err
+		}
+		conditions = append(conditions, name)
+	}
+	return And(conditions...), nil
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestFromTable(t *testing.T) {
+	spec, err := FromTable([]DecisionRow{
+		{Type: "admin", Locked: "false"},
+		{Type: "personal", NamePattern: `^svc-`},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	RunSpecTests(t, spec, []SpecCase{
+		{Name: "unlocked admin", User: &User{Type: Admin, Name: "boo"}, Expected: true},
+		{Name: "locked admin", User: &User{Type: Admin, Name: "boo", Locked: true}, Expected: false},
+		{Name: "personal service account", User: &User{Type: Personal, Name: "svc-backup", Locked: true}, Expected: true},
+		{Name: "personal user", User: &User{Type: Personal, Name: "boo"}, Expected: false},
+		{Name: "super admin", User: &User{Type: SuperAdmin, Name: "svc-root"}, Expected: false},
+	})
+}
+
+func TestFromTableEmptyCells(t *testing.T) {
+	matchAll, err := FromTable([]DecisionRow{{}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	none, err := FromTable(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, u := range typeDomain() {
+		if !matchAll.IsSatisfiedBy(u) {
+			t.Errorf("a row of empty cells does not match %+v", *u)
+		}
+		if none.IsSatisfiedBy(u) {
+			t.Errorf("an empty table matches %+v", *u)
+		}
+	}
+}
+
+func TestFromTableErrors(t *testing.T) {
+	tests := []struct {
+		rows []DecisionRow
+		err  string
+	}{
+		{[]DecisionRow{{Type: "admin"}, {Type: "root"}}, `decision table: row 2: unknown user type "root"`},
+		{[]DecisionRow{{Locked: "maybe"}}, `decision table: row 1: invalid locked "maybe"`},
+		{[]DecisionRow{{NamePattern: "("}}, "decision table: row 1: NameMatches: error parsing regexp: missing closing ): `(`"},
+	}
+	for _, tt := range tests {
+		spec, err := FromTable(tt.rows)
+		if err == nil || err.Error() != tt.err || spec != nil {
+			t.Errorf("FromTable = %v, %v, want the error %s", spec, err, tt.err)
+		}
+	}
+}
@@ -0,0 +1,311 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// SameDay: the user was created on the same calendar date as t.
+// The dates are compared in the location of t unless another one is given with In.
+type SameDaySpecification struct {
+	t   time.Time
+	loc *time.Location
+}
+
+func SameDayAs(t time.Time) *SameDaySpecification {
+	return &SameDaySpecification{
+		t:   t,
+		loc: t.Location(),
+	}
+}
+
+// In sets the time zone in which the calendar dates are compared
+func (s *SameDaySpecification) In(loc *time.Location) *SameDaySpecification {
+	s.loc = loc
+	return s
+}
+
+func (s *SameDaySpecification) IsSatisfiedBy(u *User) bool {
+	y1, m1, d1 := s.t.In(s.loc).Date()
+	y2, m2, d2 := u.CreatedAt.In(s.loc).Date()
+	return y1 == y2 && m1 == m2 && d1 == d2
+}
+
+func (s *SameDaySpecification) String() string {
+	return fmt.Sprintf("SameDayAs(%s in %s)", s.t.In(s.loc).Format("2006-01-02"), s.loc)
+}
+
+func (s *SameDaySpecification) hashKey() string {
+	return s.t.In(s.loc).Format(time.RFC3339Nano) + " in " + s.loc.String()
+}
+
+// AccountOlderThan: the user was created more than d ago
+type AccountAgeSpecification struct {
+	d   time.Duration
+	now func() time.Time
+}
+
+func AccountOlderThan(d time.Duration) *AccountAgeSpecification {
+	return &AccountAgeSpecification{
+		d:   d,
+		now: time.Now,
+	}
+}
+
+// WithClock replaces the source of the current time
+func (s *AccountAgeSpecification) WithClock(now func() time.Time) *AccountAgeSpecification {
+	s.now = now
+	return s
+}
+
+func (s *AccountAgeSpecification) IsSatisfiedBy(u *User) bool {
+	return s.now().Sub(u.CreatedAt) > s.d
+}
+
+func (s *AccountAgeSpecification) String() string {
+	return fmt.Sprintf("AccountOlderThan(%v)", s.d)
+}
+
+// FieldOlderThan: the time extracted from the user is more than d ago.
+// The zero time is infinitely old by default, see ZeroIsOld.
+type FieldAgeSpecification struct {
+	extract func(*User) time.Time
+	d       time.Duration
+	zeroOld bool
+	now     func() time.Time
+}
+
+func FieldOlderThan(extract func(*User) time.Time, d time.Duration) *FieldAgeSpecification {
+	return &FieldAgeSpecification{
+		extract: extract,
+		d:       d,
+		zeroOld: true,
+		now:     time.Now,
+	}
+}
+
+// ZeroIsOld sets whether the zero time satisfies the specification
+func (s *FieldAgeSpecification) ZeroIsOld(old bool) *FieldAgeSpecification {
+	s.zeroOld = old
+	return s
+}
+
+// WithClock replaces the source of the current time
+func (s *FieldAgeSpecification) WithClock(now func() time.Time) *FieldAgeSpecification {
+	s.now = now
+	return s
+}
+
+func (s *FieldAgeSpecification) IsSatisfiedBy(u *User) bool {
+	t := s.extract(u)
+	if t.IsZero() {
+		return s.zeroOld
+	}
+	return s.now().Sub(t) > s.d
+}
+
+func (s *FieldAgeSpecification) String() string {
+	return fmt.Sprintf("FieldOlderThan(%v, zero old: %t)", s.d, s.zeroOld)
+}
+
+// UpdatedWithin: the user was updated no more than d ago, a never updated user
+// (zero UpdatedAt) is not satisfied
+type UpdatedWithinSpecification struct {
+	d   time.Duration
+	now func() time.Time
+}
+
+func UpdatedWithin(d time.Duration) *UpdatedWithinSpecification {
+	return &UpdatedWithinSpecification{
+		d:   d,
+		now: time.Now,
+	}
+}
+
+// WithClock replaces the source of the current time
+func (s *UpdatedWithinSpecification) WithClock(now func() time.Time) *UpdatedWithinSpecification {
+	s.now = now
+	return s
+}
+
+func (s *UpdatedWithinSpecification) IsSatisfiedBy(u *User) bool {
+	return !u.UpdatedAt.IsZero() && s.now().Sub(u.UpdatedAt) <= s.d
+}
+
+func (s *UpdatedWithinSpecification) String() string {
+	return fmt.Sprintf("UpdatedWithin(%v)", s.d)
+}
+
+// ActiveBetween: inner is evaluated only while the current time is within [start, end),
+// outside the window the default (false) is returned, see Otherwise
+type ActiveBetweenSpecification struct {
+	start, end time.Time
+	inner      SpecificationUser
+	otherwise  bool
+	now        func() time.Time
+}
+
+func ActiveBetween(start, end time.Time, inner SpecificationUser) *ActiveBetweenSpecification {
+	return &ActiveBetweenSpecification{
+		start: start,
+		end:   end,
+		inner: inner,
+		now:   time.Now,
+	}
+}
+
+// Otherwise sets the result outside the window
+func (s *ActiveBetweenSpecification) Otherwise(result bool) *ActiveBetweenSpecification {
+	s.otherwise = result
+	return s
+}
+
+// WithClock replaces the source of the current time
+func (s *ActiveBetweenSpecification) WithClock(now func() time.Time) *ActiveBetweenSpecification {
+	s.now = now
+	return s
+}
+
+func (s *ActiveBetweenSpecification) Inner() SpecificationUser {
+	return s.inner
+}
+
+func (s *ActiveBetweenSpecification) IsSatisfiedBy(u *User) bool {
+	now := s.now()
+	if now.Before(s.start) || !now.Before(s.end) {
+		return s.otherwise
+	}
+	return s.inner.IsSatisfiedBy(u)
+}
+
+func (s *ActiveBetweenSpecification) String() string {
+	return fmt.Sprintf("ActiveBetween(%s, %s, %s, otherwise %t)",
+		s.start.Format(time.RFC3339Nano), s.end.Format(time.RFC3339Nano), specString(s.inner), s.otherwise)
+}
+
+func (s *ActiveBetweenSpecification) hashKey() string {
+	return fmt.Sprintf("%s %s %t", s.start.UTC().Format(time.RFC3339Nano), s.end.UTC().Format(time.RFC3339Nano), s.otherwise)
+}
+
+// ScheduledForDeletion: the DeleteAfter time of the user has come (now is not before it),
+// DeletionPending: it is still in the future. A zero DeleteAfter satisfies neither.
+type DeletionSpecification struct {
+	pending bool
+	now     func() time.Time
+}
+
+func ScheduledForDeletion() *DeletionSpecification {
+	return &DeletionSpecification{
+		now: time.Now,
+	}
+}
+
+func DeletionPending() *DeletionSpecification {
+	return &DeletionSpecification{
+		pending: true,
+		now:     time.Now,
+	}
+}
+
+// WithClock replaces the source of the current time
+func (s *DeletionSpecification) WithClock(now func() time.Time) *DeletionSpecification {
+	s.now = now
+	return s
+}
+
+func (s *DeletionSpecification) IsSatisfiedBy(u *User) bool {
+	if u.DeleteAfter.IsZero() {
+		return false
+	}
+	return s.now().Before(u.DeleteAfter) == s.pending
+}
+
+func (s *DeletionSpecification) String() string {
+	if s.pending {
+		return "DeletionPending"
+	}
+	return "ScheduledForDeletion"
+}
+
+// OnWeekdays: the current day is one of the days, Monday to Friday when none given.
+// The day is taken in the local time zone unless another one is given with In.
+type WeekdaySpecification struct {
+	days map[time.Weekday]struct{}
+	loc  *time.Location
+	now  func() time.Time
+}
+
+func OnWeekdays(days ...time.Weekday) *WeekdaySpecification {
+	if len(days) == 0 {
+		days = []time.Weekday{time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday}
+	}
+	s := &WeekdaySpecification{
+		days: make(map[time.Weekday]struct{}, len(days)),
+		loc:  time.Local,
+		now:  time.Now,
+	}
+	for _, day := range days {
+		s.days[day] = struct{}{}
+	}
+	return s
+}
+
+// In sets the time zone of the current day
+func (s *WeekdaySpecification) In(loc *time.Location) *WeekdaySpecification {
+	s.loc = loc
+	return s
+}
+
+// WithClock replaces the source of the current time
+func (s *WeekdaySpecification) WithClock(now func() time.Time) *WeekdaySpecification {
+	s.now = now
+	return s
+}
+
+func (s *WeekdaySpecification) IsSatisfiedBy(u *User) bool {
+	_, ok := s.days[s.now().In(s.loc).Weekday()]
+	return ok
+}
+
+func (s *WeekdaySpecification) String() string {
+	days := make([]string, 0, len(s.days))
+	for day := time.Sunday; day <= time.Saturday; day++ {
+		if _, ok := s.days[day]; ok {
+			days = append(days, day.String())
+		}
+	}
+	return fmt.Sprintf("OnWeekdays(%s in %s)", strings.Join(days, ", "), s.loc)
+}
+
+func (s *WeekdaySpecification) hashKey() string {
+	return s.String()
+}
+
+// NameChangedWithin: the user was renamed no more than d ago, a never renamed user
+// (zero NameChangedAt) is not satisfied
+type NameChangedWithinSpecification struct {
+	d   time.Duration
+	now func() time.Time
+}
+
+func NameChangedWithin(d time.Duration) *NameChangedWithinSpecification {
+	return &NameChangedWithinSpecification{
+		d:   d,
+		now: time.Now,
+	}
+}
+
+// WithClock replaces the source of the current time
+func (s *NameChangedWithinSpecification) WithClock(now func() time.Time) *NameChangedWithinSpecification {
+	s.now = now
+	return s
+}
+
+func (s *NameChangedWithinSpecification) IsSatisfiedBy(u *User) bool {
+	return !u.NameChangedAt.IsZero() && s.now().Sub(u.NameChangedAt) <= s.d
+}
+
+func (s *NameChangedWithinSpecification) String() string {
+	return fmt.Sprintf("NameChangedWithin(%v)", s.d)
+}
@@ -0,0 +1,203 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSameDayAsAroundMidnight(t *testing.T) {
+	jst := time.FixedZone("JST", 9*60*60)
+	est := time.FixedZone("EST", -5*60*60)
+	ref := time.Date(2026, 3, 10, 23, 30, 0, 0, time.UTC)
+	tests := []struct {
+		created       time.Time
+		utc, jst, est bool
+	}{
+		{time.Date(2026, 3, 11, 0, 15, 0, 0, time.UTC), false, true, true},
+		{time.Date(2026, 3, 10, 0, 0, 0, 0, time.UTC), true, false, false},
+		{time.Date(2026, 3, 11, 4, 59, 0, 0, time.UTC), false, true, true},
+		{time.Date(2026, 3, 11, 5, 0, 0, 0, time.UTC), false, true, false},
+		{time.Date(2026, 3, 10, 14, 59, 0, 0, time.UTC), true, false, true},
+		{time.Date(2026, 3, 10, 15, 0, 0, 0, time.UTC), true, true, true},
+	}
+	for _, tt := range tests {
+		u := &User{CreatedAt: tt.created}
+		zones := []struct {
+			spec *SameDaySpecification
+			want bool
+		}{
+			{SameDayAs(ref), tt.utc},
+			{SameDayAs(ref).In(jst), tt.jst},
+			{SameDayAs(ref).In(est), tt.est},
+			// without In the location of the reference time is used
+			{SameDayAs(ref.In(jst)), tt.jst},
+		}
+		for _, z := range zones {
+			if got := z.spec.IsSatisfiedBy(u); got != z.want {
+				t.Errorf("%s.IsSatisfiedBy(created %s) = %v, want %v", z.spec, tt.created.Format(time.RFC3339), got, z.want)
+			}
+		}
+	}
+}
+
+func TestFieldOlderThan(t *testing.T) {
+	now := time.Date(2026, 1, 10, 0, 0, 0, 0, time.UTC)
+	clock := func() time.Time { return now }
+	lockedAt := func(u *User) time.Time { return u.LockedAt }
+	tests := []struct {
+		name     string
+		zeroOld  bool
+		lockedAt time.Time
+		want     bool
+	}{
+		{"older", true, now.Add(-25 * time.Hour), true},
+		{"exactly d", true, now.Add(-24 * time.Hour), false},
+		{"newer", true, now.Add(-time.Hour), false},
+		{"zero is old", true, time.Time{}, true},
+		{"zero is not old", false, time.Time{}, false},
+		{"older with zero not old", false, now.Add(-25 * time.Hour), true},
+	}
+	for _, tt := range tests {
+		s := FieldOlderThan(lockedAt, 24*time.Hour).ZeroIsOld(tt.zeroOld).WithClock(clock)
+		if got := s.IsSatisfiedBy(&User{LockedAt: tt.lockedAt}); got != tt.want {
+			t.Errorf("%s: IsSatisfiedBy = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+	// the zero time is old by default
+	if !FieldOlderThan(lockedAt, time.Hour).IsSatisfiedBy(&User{}) {
+		t.Errorf("the zero time is not old by default")
+	}
+}
+
+func TestUpdatedWithin(t *testing.T) {
+	now := time.Date(2026, 1, 10, 12, 0, 0, 0, time.UTC)
+	s := UpdatedWithin(time.Hour).WithClock(func() time.Time { return now })
+	RunSpecTests(t, s, []SpecCase{
+		{Name: "just updated", User: &User{UpdatedAt: now}, Expected: true},
+		{Name: "updated exactly d ago", User: &User{UpdatedAt: now.Add(-time.Hour)}, Expected: true},
+		{Name: "updated before the window", User: &User{UpdatedAt: now.Add(-time.Hour - time.Second)}, Expected: false},
+		{Name: "never updated", User: &User{}, Expected: false},
+	})
+}
+
+func TestActiveBetween(t *testing.T) {
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	end := start.Add(24 * time.Hour)
+	clock := &fakeClock{}
+	tests := []struct {
+		name      string
+		now       time.Time
+		otherwise bool
+		want      bool
+	}{
+		{"before the window", start.Add(-time.Nanosecond), false, false},
+		{"start is inclusive", start, false, true},
+		{"in the window", start.Add(time.Hour), false, true},
+		{"last instant", end.Add(-time.Nanosecond), false, true},
+		{"end is exclusive", end, false, false},
+		{"after the window", end.Add(time.Hour), false, false},
+		{"configured default before", start.Add(-time.Hour), true, true},
+		{"configured default after", end, true, true},
+	}
+	for _, tt := range tests {
+		clock.t = tt.now
+		// the inner passes for the unlocked user, fails for the locked one
+		s := ActiveBetween(start, end, NotLocked).Otherwise(tt.otherwise).WithClock(clock.now)
+		if got := s.IsSatisfiedBy(&User{}); got != tt.want {
+			t.Errorf("%s: IsSatisfiedBy = %v, want %v", tt.name, got, tt.want)
+		}
+		inWindow := !tt.now.Before(start) && tt.now.Before(end)
+		if got := s.IsSatisfiedBy(&User{Locked: true}); inWindow && got {
+			t.Errorf("%s: IsSatisfiedBy of a denied user = true, want the inner result", tt.name)
+		} else if !inWindow && got != tt.otherwise {
+			t.Errorf("%s: IsSatisfiedBy of a denied user = %v, want the default %v", tt.name, got, tt.otherwise)
+		}
+	}
+}
+
+func TestDeletionSchedule(t *testing.T) {
+	now := time.Date(2026, 1, 10, 12, 0, 0, 0, time.UTC)
+	clock := func() time.Time { return now }
+	tests := []struct {
+		name               string
+		deleteAfter        time.Time
+		scheduled, pending bool
+	}{
+		{"past", now.Add(-time.Hour), true, false},
+		{"exact boundary is due", now, true, false},
+		{"a nanosecond ahead", now.Add(time.Nanosecond), false, true},
+		{"future", now.Add(24 * time.Hour), false, true},
+		{"zero is not scheduled", time.Time{}, false, false},
+	}
+	scheduled := ScheduledForDeletion().WithClock(clock)
+	pending := DeletionPending().WithClock(clock)
+	for _, tt := range tests {
+		u := &User{DeleteAfter: tt.deleteAfter}
+		if got := scheduled.IsSatisfiedBy(u); got != tt.scheduled {
+			t.Errorf("%s: ScheduledForDeletion = %v, want %v", tt.name, got, tt.scheduled)
+		}
+		if got := pending.IsSatisfiedBy(u); got != tt.pending {
+			t.Errorf("%s: DeletionPending = %v, want %v", tt.name, got, tt.pending)
+		}
+	}
+}
+
+func TestOnWeekdays(t *testing.T) {
+	jst := time.FixedZone("JST", 9*60*60)
+	tests := []struct {
+		name string
+		spec *WeekdaySpecification
+		now  time.Time
+		want bool
+	}{
+		{"weekday grant", OnWeekdays(), time.Date(2026, 1, 12, 9, 0, 0, 0, time.UTC), true},
+		{"friday grant", OnWeekdays(), time.Date(2026, 1, 9, 23, 59, 0, 0, time.UTC), true},
+		{"weekend denial", OnWeekdays(), time.Date(2026, 1, 10, 12, 0, 0, 0, time.UTC), false},
+		{"sunday denial", OnWeekdays(), time.Date(2026, 1, 11, 12, 0, 0, 0, time.UTC), false},
+		{"friday in UTC is saturday in JST", OnWeekdays().In(jst), time.Date(2026, 1, 9, 20, 0, 0, 0, time.UTC), false},
+		{"listed day", OnWeekdays(time.Saturday), time.Date(2026, 1, 10, 12, 0, 0, 0, time.UTC), true},
+		{"unlisted day", OnWeekdays(time.Saturday), time.Date(2026, 1, 12, 12, 0, 0, 0, time.UTC), false},
+	}
+	for _, tt := range tests {
+		now := tt.now
+		s := tt.spec.WithClock(func() time.Time { return now })
+		if tt.spec.loc == time.Local {
+			s.In(time.UTC)
+		}
+		if got := s.IsSatisfiedBy(&User{}); got != tt.want {
+			t.Errorf("%s: IsSatisfiedBy = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+	if got, want := OnWeekdays(time.Friday, time.Monday).In(time.UTC).String(), "OnWeekdays(Monday, Friday in UTC)"; got != want {
+		t.Errorf("String = %s, want %s", got, want)
+	}
+}
+
+func TestNameChangedWithin(t *testing.T) {
+	now := time.Date(2026, 1, 10, 12, 0, 0, 0, time.UTC)
+	s := NameChangedWithin(24 * time.Hour).WithClock(func() time.Time { return now })
+	tests := []struct {
+		name      string
+		changedAt time.Time
+		want      bool
+	}{
+		{"just renamed", now, true},
+		{"an hour ago", now.Add(-time.Hour), true},
+		{"exactly d ago", now.Add(-24 * time.Hour), true},
+		{"just over d ago", now.Add(-24*time.Hour - time.Nanosecond), false},
+		{"never renamed", time.Time{}, false},
+	}
+	for _, tt := range tests {
+		if got := s.IsSatisfiedBy(&User{NameChangedAt: tt.changedAt}); got != tt.want {
+			t.Errorf("%s: IsSatisfiedBy = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+
+	// a recent rename of a non-admin is suspicious
+	suspicious := And(s, NotAdmin)
+	RunSpecTests(t, suspicious, []SpecCase{
+		{Name: "renamed personal", User: &User{Type: Personal, NameChangedAt: now.Add(-time.Hour)}, Expected: true},
+		{Name: "renamed admin", User: &User{Type: Admin, NameChangedAt: now.Add(-time.Hour)}, Expected: false},
+		{Name: "never renamed personal", User: &User{Type: Personal}, Expected: false},
+	})
+}
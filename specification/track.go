@@ -0,0 +1,66 @@
+package main
+
+import (
+	"sync"
+	"time"
+)
+
+// TrackFailures records the time of the first denial of each key, so a caller can
+// escalate after repeated denials for some time. The later denials keep the first
+// time, a grant clears it.
+type TrackFailuresSpecification struct {
+	spec  SpecificationUser
+	keyFn func(*User) string
+	now   func() time.Time
+
+	mu    sync.Mutex
+	first map[string]time.Time `hash:"-"`
+}
+
+func TrackFailures(spec SpecificationUser, keyFn func(*User) string) *TrackFailuresSpecification {
+	return &TrackFailuresSpecification{
+		spec:  spec,
+		keyFn: keyFn,
+		now:   time.Now,
+		first: make(map[string]time.Time),
+	}
+}
+
+// WithClock replaces the source of the current time
+func (s *TrackFailuresSpecification) WithClock(now func() time.Time) *TrackFailuresSpecification {
+	s.now = now
+	return s
+}
+
+func (s *TrackFailuresSpecification) IsSatisfiedBy(u *User) bool {
+	ok := s.spec.IsSatisfiedBy(u)
+	key := s.keyFn(u)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if ok {
+		delete(s.first, key)
+	} else if _, found := s.first[key]; !found {
+		s.first[key] = s.now()
+	}
+	return ok
+}
+
+// FailureAge returns the time since the first denial of the key,
+// false if the key is not being denied
+func (s *TrackFailuresSpecification) FailureAge(key string) (time.Duration, bool) {
+	s.mu.Lock()
+	first, ok := s.first[key]
+	s.mu.Unlock()
+	if !ok {
+		return 0, false
+	}
+	return s.now().Sub(first), true
+}
+
+func (s *TrackFailuresSpecification) Inner() SpecificationUser {
+	return s.spec
+}
+
+func (s *TrackFailuresSpecification) String() string {
+	return "TrackFailures(" + specString(s.spec) + ")"
+}
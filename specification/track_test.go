@@ -0,0 +1,39 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTrackFailures(t *testing.T) {
+	clock := &fakeClock{t: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)}
+	s := TrackFailures(NotLocked, userName).WithClock(clock.now)
+	boo := &User{Name: "boo", Locked: true}
+	steps := []struct {
+		name    string
+		advance time.Duration
+		locked  bool
+		age     time.Duration
+		denied  bool
+	}{
+		{"first denial sets the time", 0, true, 0, true},
+		{"later denial keeps it", time.Minute, true, time.Minute, true},
+		{"age grows with the clock", 4 * time.Minute, true, 5 * time.Minute, true},
+		{"grant clears it", time.Minute, false, 0, false},
+		{"next denial starts over", time.Minute, true, 0, true},
+	}
+	for _, step := range steps {
+		clock.t = clock.t.Add(step.advance)
+		boo.Locked = step.locked
+		if got := s.IsSatisfiedBy(boo); got == step.locked {
+			t.Errorf("%s: IsSatisfiedBy = %v, want the inner result %v", step.name, got, !step.locked)
+		}
+		age, denied := s.FailureAge("boo")
+		if age != step.age || denied != step.denied {
+			t.Errorf("%s: FailureAge = %v, %v, want %v, %v", step.name, age, denied, step.age, step.denied)
+		}
+	}
+	if _, denied := s.FailureAge("foo"); denied {
+		t.Errorf("FailureAge of a key never evaluated reports a denial")
+	}
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// Composite is implemented by specifications combining several specifications
+type Composite interface {
+	Children() []SpecificationUser
+}
+
+// Wrapper is implemented by specifications decorating a single specification
+type Wrapper interface {
+	Inner() SpecificationUser
+}
+
+// Leaves returns every non-composite specification of the tree in depth-first order.
+// A leaf returns itself.
+func Leaves(spec SpecificationUser) []SpecificationUser {
+	switch s := spec.(type) {
+	case Composite:
+		var leaves []SpecificationUser
+		for _, child := range s.Children() {
+			leaves = append(leaves, Leaves(child)...)
+		}
+		return leaves
+	case Wrapper:
+		return Leaves(s.Inner())
+	}
+	return []SpecificationUser{spec}
+}
+
+// SemanticallyEqual reports whether both specifications agree on every user of the domain.
+// It is only as good as the domain sample: the specifications may still differ on users
+// outside of it.
+func SemanticallyEqual(a, b SpecificationUser, domain []*User) bool {
+	for _, u := range domain {
+		if a.IsSatisfiedBy(u) != b.IsSatisfiedBy(u) {
+			return false
+		}
+	}
+	return true
+}
+
+// MinimalCause returns the leaves whose outcomes determine the result for the user:
+//   - a leaf is its own cause;
+//   - a failed And is caused by its first failing child, a satisfied And by all children;
+//   - a satisfied Or is caused by its first satisfied child, a failed Or by all children;
+//   - NoneOf is Not(Or(...)): failed by its first triggered child, satisfied by all children;
+//   - Not has the cause of its inner specification.
+//
+// The causes of the children are taken recursively, other specifications are leaves.
+func MinimalCause(spec SpecificationUser, u *User) []SpecificationUser {
+	switch s := spec.(type) {
+	case *AndSpecification:
+		return minimalCause(s.specs, u, false)
+	case *OrSpecification:
+		return minimalCause(s.specs, u, true)
+	case *NoneOfSpecification:
+		return minimalCause(s.specs, u, true)
+	case *NotSpecification:
+		return MinimalCause(s.spec, u)
+	}
+	return []SpecificationUser{spec}
+}
+
+// minimalCause returns the cause of the first child returning decisive,
+// or the causes of all children if none does
+func minimalCause(specs []SpecificationUser, u *User, decisive bool) []SpecificationUser {
+	var causes []SpecificationUser
+	for _, spec := range specs {
+		if spec.IsSatisfiedBy(u) == decisive {
+			return MinimalCause(spec, u)
+		}
+		causes = append(causes, MinimalCause(spec, u)...)
+	}
+	return causes
+}
+
+// Dedup returns an equivalent tree in which the equal leaves are one shared instance.
+// Leaves are equal if they have the same type and reflect.DeepEqual values; leaves
+// holding functions are never equal, so the semantics are preserved. And, Or, Not and
+// NoneOf are rebuilt, the other specifications are kept as they are.
+func Dedup(spec SpecificationUser) SpecificationUser {
+	return dedup(spec, make(map[uint64][]SpecificationUser))
+}
+
+func dedup(spec SpecificationUser, seen map[uint64][]SpecificationUser) SpecificationUser {
+	switch s := spec.(type) {
+	case *AndSpecification:
+		return And(dedupAll(s.specs, seen)...)
+	case *OrSpecification:
+		return Or(dedupAll(s.specs, seen)...)
+	case *NoneOfSpecification:
+		return NoneOf(dedupAll(s.specs, seen)...)
+	case *NotSpecification:
+		return Not(dedup(s.spec, seen))
+	case Composite, Wrapper:
+		return spec
+	}
+	h := Hash(spec)
+	for _, other := range seen[h] {
+		if reflect.TypeOf(other) == reflect.TypeOf(spec) && reflect.DeepEqual(other, spec) {
+			return other
+		}
+	}
+	seen[h] = append(seen[h], spec)
+	return spec
+}
+
+func dedupAll(specs []SpecificationUser, seen map[uint64][]SpecificationUser) []SpecificationUser {
+	result := make([]SpecificationUser, len(specs))
+	for i, spec := range specs {
+		result[i] = dedup(spec, seen)
+	}
+	return result
+}
+
+// ValidateComplexity returns an error if the tree is deeper than maxDepth or has more
+// than maxLeaves leaves, to reject abusive rules from the DSL or the configuration.
+// A leaf has depth 1, each Composite or Wrapper above it adds a level.
+func ValidateComplexity(spec SpecificationUser, maxDepth, maxLeaves int) error {
+	depth, leaves := treeShape(spec)
+	if depth > maxDepth {
+		return fmt.Errorf("complexity: depth %d exceeds the limit of %d", depth, maxDepth)
+	}
+	if leaves > maxLeaves {
+		return fmt.Errorf("complexity: %d leaves exceed the limit of %d", leaves, maxLeaves)
+	}
+	return nil
+}
+
+// treeShape returns the depth and the number of leaves of the tree
+func treeShape(spec SpecificationUser) (depth, leaves int) {
+	var children []SpecificationUser
+	switch s := spec.(type) {
+	case Composite:
+		children = s.Children()
+	case Wrapper:
+		children = []SpecificationUser{s.Inner()}
+	default:
+		return 1, 1
+	}
+	for _, child := range children {
+		d, l := treeShape(child)
+		if d > depth {
+			depth = d
+		}
+		leaves += l
+	}
+	return depth + 1, leaves
+}
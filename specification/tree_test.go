@@ -0,0 +1,161 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestLeaves(t *testing.T) {
+	tests := []struct {
+		name string
+		spec SpecificationUser
+		want []SpecificationUser
+	}{
+		{"ValidNameNotAdmin", ValidNameNotAdmin, []SpecificationUser{IsAdmin, IsSuperAdmin, Locked, IsNameShort4}},
+		{"single leaf", IsAdmin, []SpecificationUser{IsAdmin}},
+		{"wrapper", Not(Locked), []SpecificationUser{Locked}},
+		{"shared leaf", And(IsAdmin, Or(IsAdmin, Locked)), []SpecificationUser{IsAdmin, IsAdmin, Locked}},
+		{"empty And", And(), nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Leaves(tt.spec)
+			if len(got) != len(tt.want) {
+				t.Fatalf("Leaves returned %d leaves, want %d", len(got), len(tt.want))
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("leaf %d = %s, want %s", i, specString(got[i]), specString(tt.want[i]))
+				}
+			}
+		})
+	}
+}
+
+// typeDomain has a user of every type, locked and not, plus an unknown type
+func typeDomain() []*User {
+	var domain []*User
+	for _, typ := range []UserType{Personal, Admin, SuperAdmin, 42} {
+		domain = append(domain, &User{Type: typ}, &User{Type: typ, Locked: true})
+	}
+	return domain
+}
+
+func TestSemanticallyEqual(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b SpecificationUser
+		want bool
+	}{
+		{"De Morgan", Not(AnyAdmin), And(NotSuperAdmin, Not(IsAdmin)), true},
+		{"double negation", Not(Not(Locked)), Locked, true},
+		{"TypeIn", AnyAdmin, TypeIn(Admin, SuperAdmin), true},
+		{"different types", AnyAdmin, IsAdmin, false},
+		{"different fields", NotAdmin, NotLocked, false},
+	}
+	domain := typeDomain()
+	for _, tt := range tests {
+		if got := SemanticallyEqual(tt.a, tt.b, domain); got != tt.want {
+			t.Errorf("%s: SemanticallyEqual(%s, %s) = %v, want %v", tt.name, specString(tt.a), specString(tt.b), got, tt.want)
+		}
+	}
+}
+
+func TestSemanticallyEqualDomainSample(t *testing.T) {
+	// the domain of personal users does not tell the specifications apart
+	domain := []*User{{Type: Personal}}
+	if !SemanticallyEqual(IsAdmin, IsSuperAdmin, domain) {
+		t.Errorf("specifications differ on a domain where both fail")
+	}
+}
+
+func TestMinimalCause(t *testing.T) {
+	short := Name("boo")
+	tests := []struct {
+		name string
+		spec SpecificationUser
+		user *User
+		want []SpecificationUser
+	}{
+		{"leaf", IsAdmin, &User{}, []SpecificationUser{IsAdmin}},
+		{"failed And: first failing child", And(NotLocked, IsAdmin, short), &User{Name: "foo"}, []SpecificationUser{IsAdmin}},
+		{"satisfied And: all children", And(NotLocked, short), &User{Name: "boo"}, []SpecificationUser{Locked, short}},
+		{"satisfied Or: first satisfied child", Or(IsAdmin, short, NotLocked), &User{Name: "boo"}, []SpecificationUser{short}},
+		{"failed Or: all children", Or(IsAdmin, Locked), &User{}, []SpecificationUser{IsAdmin, Locked}},
+		{"failed NoneOf: first triggered", NoneOf(IsAdmin, short, Locked), &User{Name: "boo", Locked: true}, []SpecificationUser{short}},
+		{"satisfied NoneOf: all children", NoneOf(IsAdmin, Locked), &User{}, []SpecificationUser{IsAdmin, Locked}},
+		{"nested", And(Or(IsAdmin, IsSuperAdmin), Not(And(Locked, short))), &User{Type: SuperAdmin, Name: "boo"},
+			[]SpecificationUser{IsSuperAdmin, Locked}},
+		{"ValidNameNotAdmin denied", ValidNameNotAdmin, &User{Type: Admin, Name: "alexander"}, []SpecificationUser{IsAdmin}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := MinimalCause(tt.spec, tt.user)
+			if len(got) != len(tt.want) {
+				t.Fatalf("MinimalCause = %s, want %s", joinSpecStrings(got), joinSpecStrings(tt.want))
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("MinimalCause = %s, want %s", joinSpecStrings(got), joinSpecStrings(tt.want))
+					break
+				}
+			}
+		})
+	}
+}
+
+func TestDedupSharesEqualLeaves(t *testing.T) {
+	spec := And(NameShort(4), Or(IsAdmin, NameShort(4)), Not(NameShort(4)), NameShort(5))
+	deduped := Dedup(spec)
+	leaves := Leaves(deduped)
+	if len(leaves) != 5 {
+		t.Fatalf("Dedup changed the number of leaves to %d", len(leaves))
+	}
+	if leaves[0] != leaves[2] || leaves[0] != leaves[3] {
+		t.Errorf("the three NameShort(4) leaves are not one instance")
+	}
+	if leaves[0] == leaves[4] {
+		t.Errorf("NameShort(5) shares the instance of NameShort(4)")
+	}
+	if !SemanticallyEqual(spec, deduped, bitsetUsers()) {
+		t.Errorf("Dedup changed the semantics: %s", specString(deduped))
+	}
+}
+
+func TestDedupKeepsFunctionLeaves(t *testing.T) {
+	isBoo := func(u *User) bool { return u.Name == "boo" }
+	spec := And(InGroup("ops", nil), InGroup("ops", nil), Requires(map[string]func(*User) bool{"name": isBoo}),
+		Requires(map[string]func(*User) bool{"name": isBoo}))
+	leaves := Leaves(Dedup(spec))
+	if leaves[0] != leaves[1] {
+		t.Errorf("equal InGroup leaves without a resolver are not shared")
+	}
+	if leaves[2] == leaves[3] {
+		t.Errorf("leaves holding functions are shared")
+	}
+}
+
+func TestValidateComplexity(t *testing.T) {
+	// ValidNameNotAdmin is 4 levels deep with 4 leaves
+	tests := []struct {
+		name                string
+		spec                SpecificationUser
+		maxDepth, maxLeaves int
+		err                 string
+	}{
+		{"at the limits", ValidNameNotAdmin, 4, 4, ""},
+		{"under the limits", ValidNameNotAdmin, 10, 10, ""},
+		{"one leaf too many", ValidNameNotAdmin, 4, 3, "complexity: 4 leaves exceed the limit of 3"},
+		{"one level too deep", ValidNameNotAdmin, 3, 4, "complexity: depth 4 exceeds the limit of 3"},
+		{"single leaf", IsAdmin, 1, 1, ""},
+		{"wrapper adds a level", NotIf(true, IsAdmin), 1, 1, "complexity: depth 2 exceeds the limit of 1"},
+		{"empty composite", And(), 1, 0, ""},
+	}
+	for _, tt := range tests {
+		err := ValidateComplexity(tt.spec, tt.maxDepth, tt.maxLeaves)
+		if tt.err == "" && err != nil {
+			t.Errorf("%s: ValidateComplexity = %v, want nil", tt.name, err)
+		} else if tt.err != "" && (err == nil || err.Error() != tt.err) {
+			t.Errorf("%s: ValidateComplexity = %v, want %s", tt.name, err, tt.err)
+		}
+	}
+}
@@ -0,0 +1,43 @@
+package main
+
+import "time"
+
+// TrustOptions configures the composite built by Trusted
+type TrustOptions struct {
+	// RequireUnlocked rejects locked users
+	RequireUnlocked bool
+	// RejectPlaceholder rejects placeholder names like "user" or "test"
+	RejectPlaceholder bool
+	// MinAccountAgeDays requires the account to be older than the number of days, 0 disables the check
+	MinAccountAgeDays int
+	// AllowAdmins accepts admins and super admins, they are rejected by default
+	AllowAdmins bool
+	// Now is the clock of the account age check, time.Now if nil
+	Now func() time.Time
+}
+
+// Trusted assembles the "trusted user" specification from the options:
+//
+//	And(NotLocked, Not(PlaceholderName), AccountOlderThan(N days), NotAdmin)
+//
+// leaving out the disabled checks
+func Trusted(opts TrustOptions) SpecificationUser {
+	var specs []SpecificationUser
+	if opts.RequireUnlocked {
+		specs = append(specs, NotLocked)
+	}
+	if opts.RejectPlaceholder {
+		specs = append(specs, Not(&PlaceholderNameSpecification{}))
+	}
+	if opts.MinAccountAgeDays > 0 {
+		age := AccountOlderThan(time.Duration(opts.MinAccountAgeDays) * 24 * time.Hour)
+		if opts.Now != nil {
+			age.WithClock(opts.Now)
+		}
+		specs = append(specs, age)
+	}
+	if !opts.AllowAdmins {
+		specs = append(specs, NotAdmin)
+	}
+	return And(specs...)
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTrustedOptions(t *testing.T) {
+	now := time.Date(2026, 1, 10, 0, 0, 0, 0, time.UTC)
+	clock := func() time.Time { return now }
+	// the user fails every check, so each option alone decides
+	u := &User{Type: Admin, Name: "test", Locked: true, CreatedAt: now.AddDate(0, 0, -2)}
+	base := TrustOptions{AllowAdmins: true, Now: clock}
+	if !Trusted(base).IsSatisfiedBy(u) {
+		t.Fatalf("Trusted without checks rejects the user")
+	}
+	tests := []struct {
+		name   string
+		toggle func(o *TrustOptions)
+		want   bool
+	}{
+		{"RequireUnlocked", func(o *TrustOptions) { o.RequireUnlocked = true }, false},
+		{"RejectPlaceholder", func(o *TrustOptions) { o.RejectPlaceholder = true }, false},
+		{"MinAccountAgeDays above the age", func(o *TrustOptions) { o.MinAccountAgeDays = 7 }, false},
+		{"MinAccountAgeDays below the age", func(o *TrustOptions) { o.MinAccountAgeDays = 1 }, true},
+		{"AllowAdmins off", func(o *TrustOptions) { o.AllowAdmins = false }, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := base
+			tt.toggle(&opts)
+			spec := Trusted(opts)
+			if got := spec.IsSatisfiedBy(u); got != tt.want {
+				t.Errorf("%s.IsSatisfiedBy = %v, want %v", specString(spec), got, tt.want)
+			}
+		})
+	}
+}
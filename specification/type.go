@@ -0,0 +1,126 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Specification type: one of the listed types
+type TypeInSpecification struct {
+	types map[UserType]struct{}
+}
+
+// TypeIn builds the set of types once, so IsSatisfiedBy is a map lookup without allocations
+func TypeIn(types ...UserType) *TypeInSpecification {
+	set := make(map[UserType]struct{}, len(types))
+	for _, typ := range types {
+		set[typ] = struct{}{}
+	}
+	return &TypeInSpecification{
+		types: set,
+	}
+}
+
+func (s *TypeInSpecification) IsSatisfiedBy(u *User) bool {
+	_, ok := s.types[u.Type]
+	return ok
+}
+
+func (s *TypeInSpecification) String() string {
+	names := make([]string, 0, len(s.types))
+	for typ := range s.types {
+		names = append(names, typ.String())
+	}
+	sort.Strings(names)
+	return "TypeIn(" + strings.Join(names, ", ") + ")"
+}
+
+// ByType dispatches to the specification of the user type,
+// the types without a rule use def, a nil def is always satisfied
+type ByTypeSpecification struct {
+	rules map[UserType]SpecificationUser
+	def   SpecificationUser
+}
+
+func ByType(rules map[UserType]SpecificationUser, def SpecificationUser) *ByTypeSpecification {
+	s := &ByTypeSpecification{
+		rules: make(map[UserType]SpecificationUser, len(rules)),
+		def:   def,
+	}
+	for typ, spec := range rules {
+		s.rules[typ] = spec
+	}
+	return s
+}
+
+func (s *ByTypeSpecification) IsSatisfiedBy(u *User) bool {
+	if spec, ok := s.rules[u.Type]; ok {
+		return spec.IsSatisfiedBy(u)
+	}
+	if s.def == nil {
+		return true
+	}
+	return s.def.IsSatisfiedBy(u)
+}
+
+func (s *ByTypeSpecification) String() string {
+	types := make([]int, 0, len(s.rules))
+	for typ := range s.rules {
+		types = append(types, int(typ))
+	}
+	sort.Ints(types)
+	parts := make([]string, 0, len(types)+1)
+	for _, typ := range types {
+		parts = append(parts, UserType(typ).String()+": "+specString(s.rules[UserType(typ)]))
+	}
+	if s.def != nil {
+		parts = append(parts, "default: "+specString(s.def))
+	}
+	return "ByType(" + strings.Join(parts, ", ") + ")"
+}
+
+// variants of the user type names accepted by ParseUserType, normalized by normalizeTypeName
+var userTypeVariants = map[string]UserType{
+	"personal":      Personal,
+	"admin":         Admin,
+	"administrator": Admin,
+	"superadmin":    SuperAdmin,
+	"superuser":     SuperAdmin,
+}
+
+// normalizeTypeName lower-cases the name and drops spaces, dashes and underscores,
+// so "SUPER ADMIN", "super_admin" and "SuperAdmin" are the same
+func normalizeTypeName(name string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsSpace(r) || r == '-' || r == '_' {
+			return -1
+		}
+		return unicode.ToLower(r)
+	}, name)
+}
+
+// ParseUserType returns the type by its name (as returned by String) or a common variant
+func ParseUserType(name string) (UserType, error) {
+	if typ, ok := userTypeVariants[normalizeTypeName(name)]; ok {
+		return typ, nil
+	}
+	return 0, fmt.Errorf("unknown user type %q", name)
+}
+
+// Specification name: not a user type name like "admin" or "Super Admin"
+type NameNotATypeSpecification struct{}
+
+func NameNotAType() *NameNotATypeSpecification {
+	return &NameNotATypeSpecification{}
+}
+
+func (s *NameNotATypeSpecification) IsSatisfiedBy(u *User) bool {
+	_, err := ParseUserType(u.Name)
+	return err != nil
+}
+
+func (s *NameNotATypeSpecification) String() string {
+	return "NameNotAType"
+}
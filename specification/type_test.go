@@ -0,0 +1,91 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestTypeIn(t *testing.T) {
+	RunSpecTests(t, TypeIn(Admin, SuperAdmin), []SpecCase{
+		{User: &User{Type: Personal}, Expected: false},
+		{User: &User{Type: Admin}, Expected: true},
+		{User: &User{Type: SuperAdmin}, Expected: true},
+		{Name: "unknown type", User: &User{Type: 42}, Expected: false},
+	})
+	RunSpecTests(t, TypeIn(), []SpecCase{
+		{Name: "no types", User: &User{Type: Personal}, Expected: false},
+	})
+}
+
+func TestTypeInAllocations(t *testing.T) {
+	s := typeIn50()
+	u := &User{Type: 49}
+	if n := testing.AllocsPerRun(100, func() { s.IsSatisfiedBy(u) }); n != 0 {
+		t.Errorf("IsSatisfiedBy allocates %v times per call", n)
+	}
+}
+
+// typeIn50 is TypeIn of the types 0..49
+func typeIn50() *TypeInSpecification {
+	types := make([]UserType, 50)
+	for i := range types {
+		types[i] = UserType(i)
+	}
+	return TypeIn(types...)
+}
+
+func BenchmarkTypeIn(b *testing.B) {
+	s := typeIn50()
+	users := []*User{{Type: 0}, {Type: 25}, {Type: 49}, {Type: 50}}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		s.IsSatisfiedBy(users[i%len(users)])
+	}
+}
+
+func TestByType(t *testing.T) {
+	s := ByType(map[UserType]SpecificationUser{
+		Admin:      NotLocked,
+		SuperAdmin: Name("root"),
+	}, Not(IsNameShort4))
+	RunSpecTests(t, s, []SpecCase{
+		{Name: "admin branch passes", User: &User{Type: Admin, Name: "boo"}, Expected: true},
+		{Name: "admin branch fails", User: &User{Type: Admin, Name: "boo", Locked: true}, Expected: false},
+		{Name: "super admin branch passes", User: &User{Type: SuperAdmin, Name: "root"}, Expected: true},
+		{Name: "super admin branch fails", User: &User{Type: SuperAdmin, Name: "alexander"}, Expected: false},
+		{Name: "default passes", User: &User{Type: Personal, Name: "alexander", Locked: true}, Expected: true},
+		{Name: "default fails", User: &User{Type: Personal, Name: "boo"}, Expected: false},
+		{Name: "unknown type uses default", User: &User{Type: 42, Name: "boo"}, Expected: false},
+	})
+	RunSpecTests(t, ByType(map[UserType]SpecificationUser{Admin: Locked}, nil), []SpecCase{
+		{Name: "nil default", User: &User{Type: Personal}, Expected: true},
+	})
+	want := "ByType(ADMIN: Not(Locked), SUPER ADMIN: Name(root), default: Not(NameShort(4)))"
+	if got := s.String(); got != want {
+		t.Errorf("String = %s, want %s", got, want)
+	}
+}
+
+func TestNameNotAType(t *testing.T) {
+	RunSpecTests(t, NameNotAType(), []SpecCase{
+		{User: &User{Name: "ADMIN"}, Expected: false},
+		{User: &User{Name: "admin"}, Expected: false},
+		{User: &User{Name: "Super Admin"}, Expected: false},
+		{User: &User{Name: "super_admin"}, Expected: false},
+		{User: &User{Name: "Administrator"}, Expected: false},
+		{User: &User{Name: "PERSONAL"}, Expected: false},
+		{User: &User{Name: "alexander"}, Expected: true},
+		{User: &User{Name: "admin1"}, Expected: true},
+	})
+}
+
+func TestParseUserType(t *testing.T) {
+	for _, typ := range []UserType{Personal, Admin, SuperAdmin} {
+		if got, err := ParseUserType(typ.String()); got != typ || err != nil {
+			t.Errorf("ParseUserType(%s) = %v, %v", typ, got, err)
+		}
+	}
+	if _, err := ParseUserType("root"); err == nil || err.Error() != `unknown user type "root"` {
+		t.Errorf("ParseUserType(root) error = %v", err)
+	}
+}
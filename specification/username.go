@@ -0,0 +1,97 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// UsernamePolicy is the set of rules of a username, the zero value of a rule disables it
+type UsernamePolicy struct {
+	// MinLength and MaxLength bound the number of runes
+	MinLength int
+	MaxLength int
+	// AllowedChars must match the whole name, it is anchored by SatisfiesPolicy,
+	// e.g. [a-z0-9_]+
+	AllowedChars *regexp.Regexp
+	// NoLeadingDigit rejects names starting with a digit
+	NoLeadingDigit bool
+	// Reserved names are compared ignoring case and diacritics
+	Reserved []string
+}
+
+// SatisfiesPolicy: the name satisfies every rule of the policy,
+// Explain reports each violated rule. The name is normalized to NFC before
+// the checks, so a decomposed "é" is one character.
+type UsernamePolicySpecification struct {
+	policy  UsernamePolicy
+	allowed *regexp.Regexp // AllowedChars anchored at both ends
+}
+
+func SatisfiesPolicy(p UsernamePolicy) *UsernamePolicySpecification {
+	s := &UsernamePolicySpecification{
+		policy: p,
+	}
+	if p.AllowedChars != nil {
+		s.allowed = regexp.MustCompile(`^(?:` + p.AllowedChars.String() + `)$`)
+	}
+	return s
+}
+
+func (s *UsernamePolicySpecification) IsSatisfiedBy(u *User) bool {
+	return len(s.Explain(u)) == 0
+}
+
+func (s *UsernamePolicySpecification) Explain(u *User) []string {
+	return reasonMessages(s.reasons(u))
+}
+
+func (s *UsernamePolicySpecification) reasons(u *User) []reason {
+	p := s.policy
+	name := norm.NFC.String(u.Name)
+	var reasons []reason
+	length := utf8.RuneCountInString(name)
+	if p.MinLength > 0 && length < p.MinLength {
+		reasons = append(reasons, newReason(CodeNameTooShort, p.MinLength))
+	}
+	if p.MaxLength > 0 && length > p.MaxLength {
+		reasons = append(reasons, newReason(CodeNameTooLong, p.MaxLength))
+	}
+	if s.allowed != nil && !s.allowed.MatchString(name) {
+		reasons = append(reasons, newReason(CodeNameInvalidChars, p.AllowedChars))
+	}
+	if r, _ := utf8.DecodeRuneInString(name); p.NoLeadingDigit && unicode.IsDigit(r) {
+		reasons = append(reasons, newReason(CodeNameLeadingDigit))
+	}
+	folded := foldName(name)
+	for _, reserved := range p.Reserved {
+		if foldName(reserved) == folded {
+			reasons = append(reasons, newReason(CodeNameReserved, name))
+			break
+		}
+	}
+	return reasons
+}
+
+func (s *UsernamePolicySpecification) String() string {
+	p := s.policy
+	allowed := ""
+	if p.AllowedChars != nil {
+		allowed = p.AllowedChars.String()
+	}
+	return fmt.Sprintf("SatisfiesPolicy(length %d..%d, chars %q, no leading digit: %t, reserved [%s])",
+		p.MinLength, p.MaxLength, allowed, p.NoLeadingDigit, strings.Join(p.Reserved, ", "))
+}
+
+func (s *UsernamePolicySpecification) hashKey() string {
+	p := s.policy
+	allowed := ""
+	if p.AllowedChars != nil {
+		allowed = p.AllowedChars.String()
+	}
+	return fmt.Sprintf("%d %d %q %t %q", p.MinLength, p.MaxLength, allowed, p.NoLeadingDigit, p.Reserved)
+}
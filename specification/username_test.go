@@ -0,0 +1,44 @@
+package main
+
+import (
+	"reflect"
+	"regexp"
+	"testing"
+)
+
+func TestSatisfiesPolicy(t *testing.T) {
+	s := SatisfiesPolicy(UsernamePolicy{
+		MinLength:      3,
+		MaxLength:      8,
+		AllowedChars:   regexp.MustCompile(`[a-zé0-9_]+`),
+		NoLeadingDigit: true,
+		Reserved:       []string{"admin", "root"},
+	})
+	tests := []struct {
+		name    string
+		reasons []string
+	}{
+		{"alex_1", nil},
+		{"rémy", nil},
+		// the decomposed "é" is one character after NFC
+		{"re\u0301my", nil},
+		{"a!!", []string{"name: does not match [a-zé0-9_]+"}},
+		{"1a", []string{"name: shorter than 3 characters", "name: starts with a digit"}},
+		{"123456789", []string{"name: longer than 8 characters", "name: starts with a digit"}},
+		{"ROOT", []string{"name: does not match [a-zé0-9_]+", "name: ROOT is reserved"}},
+		{"ádmin", []string{"name: does not match [a-zé0-9_]+", "name: ádmin is reserved"}},
+		{"rôot", []string{"name: does not match [a-zé0-9_]+", "name: rôot is reserved"}},
+		{"admén", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u := &User{Name: tt.name}
+			if got := Explain(s, u); !reflect.DeepEqual(got, tt.reasons) {
+				t.Errorf("Explain = %q, want %q", got, tt.reasons)
+			}
+			if got, want := s.IsSatisfiedBy(u), tt.reasons == nil; got != want {
+				t.Errorf("IsSatisfiedBy = %v, want %v", got, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// validate is shared, a Validate is safe for concurrent use and caches the parsed tags
+var validate = validator.New()
+
+// FromValidatorTag applies a go-playground/validator tag, e.g. "required,min=4",
+// to the named User field with validator.Var, so every tag of the library works
+// exactly as it does there. The field and the tag are checked at construction against
+// the zero value of the field: the library panics on an unknown tag or a tag that does
+// not apply to the field.
+type ValidatorTagSpecification struct {
+	field string
+	tag   string
+}
+
+func FromValidatorTag(fieldName, tag string) (*ValidatorTagSpecification, error) {
+	f, ok := reflect.TypeOf(User{}).FieldByName(fieldName)
+	if !ok {
+		return nil, fmt.Errorf("validator: unknown field %q", fieldName)
+	}
+	if err := checkValidatorTag(reflect.Zero(f.Type).Interface(), tag); err != nil {
+		return nil, fmt.Errorf("validator: field %s: %w", fieldName, err)
+	}
+	return &ValidatorTagSpecification{
+		field: fieldName,
+		tag:   tag,
+	}, nil
+}
+
+// checkValidatorTag validates the zero value of the field, the validation errors are
+// expected and a panic of the library reports an invalid tag
+func checkValidatorTag(zero interface{}, tag string) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("invalid tag %q: %v", tag, r)
+		}
+	}()
+	if err := validate.Var(zero, tag); err != nil {
+		if _, ok := err.(validator.ValidationErrors); !ok {
+			return fmt.Errorf("invalid tag %q: %w", tag, err)
+		}
+	}
+	return nil
+}
+
+func (s *ValidatorTagSpecification) IsSatisfiedBy(u *User) bool {
+	v := reflect.ValueOf(u).Elem().FieldByName(s.field)
+	return validate.Var(v.Interface(), s.tag) == nil
+}
+
+func (s *ValidatorTagSpecification) String() string {
+	return fmt.Sprintf("FromValidatorTag(%s, %s)", s.field, s.tag)
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestFromValidatorTag(t *testing.T) {
+	tests := []struct {
+		field, tag string
+		cases      []SpecCase
+	}{
+		{"Name", "min=4", []SpecCase{
+			{Name: "short name", User: &User{Name: "boo"}, Expected: false},
+			{Name: "name at min", User: &User{Name: "alex"}, Expected: true},
+			{Name: "long name", User: &User{Name: "alexander"}, Expected: true},
+			{Name: "multibyte name counts runes", User: &User{Name: "élan"}, Expected: true},
+		}},
+		{"Name", "required,max=4", []SpecCase{
+			{Name: "empty name", User: &User{}, Expected: false},
+			{Name: "name at max", User: &User{Name: "alex"}, Expected: true},
+			{Name: "long name", User: &User{Name: "alexander"}, Expected: false},
+		}},
+		{"Email", "email", []SpecCase{
+			{Name: "valid email", User: &User{Email: "boo@x.com"}, Expected: true},
+			{Name: "display name", User: &User{Email: "Boo <boo@x.com>"}, Expected: false},
+			{Name: "no @", User: &User{Email: "boo"}, Expected: false},
+		}},
+		{"Type", "min=1,max=1", []SpecCase{
+			{Name: "admin", User: &User{Type: Admin}, Expected: true},
+			{Name: "personal", User: &User{Type: Personal}, Expected: false},
+		}},
+		{"Country", "omitempty,iso3166_1_alpha2", []SpecCase{
+			{Name: "no country", User: &User{}, Expected: true},
+			{Name: "country code", User: &User{Country: "RU"}, Expected: true},
+			{Name: "unknown code", User: &User{Country: "XX"}, Expected: false},
+		}},
+		{"Phone", "e164", []SpecCase{
+			{Name: "e164 phone", User: &User{Phone: "+79161234567"}, Expected: true},
+			{Name: "local phone", User: &User{Phone: "8 916 123-45-67"}, Expected: false},
+		}},
+		{"Name", "alpha|numeric", []SpecCase{
+			{Name: "letters", User: &User{Name: "boo"}, Expected: true},
+			{Name: "digits", User: &User{Name: "42"}, Expected: true},
+			{Name: "mixed", User: &User{Name: "boo42"}, Expected: false},
+		}},
+	}
+	for _, tt := range tests {
+		s, err := FromValidatorTag(tt.field, tt.tag)
+		if err != nil {
+			t.Fatalf("FromValidatorTag(%s, %s): %v", tt.field, tt.tag, err)
+		}
+		t.Run(s.String(), func(t *testing.T) {
+			RunSpecTests(t, s, tt.cases)
+		})
+	}
+}
+
+func TestFromValidatorTagErrors(t *testing.T) {
+	tests := []struct {
+		field, tag string
+		err        string
+	}{
+		{"Nickname", "required", `validator: unknown field "Nickname"`},
+		{"Name", "min=four", `validator: field Name: invalid tag "min=four": strconv.ParseInt: parsing "four": invalid syntax`},
+		{"Name", "uuidx", `validator: field Name: invalid tag "uuidx": Undefined validation function 'uuidx' on field ''`},
+		{"Name", "required,,min=1", `validator: field Name: invalid tag "required,,min=1": Invalid validation tag on field ''`},
+		{"Locked", "max=1", `validator: field Locked: invalid tag "max=1": Bad field type bool`},
+	}
+	for _, tt := range tests {
+		s, err := FromValidatorTag(tt.field, tt.tag)
+		if err == nil || err.Error() != tt.err || s != nil {
+			t.Errorf("FromValidatorTag(%s, %s) = %v, %v, want the error %s", tt.field, tt.tag, s, err, tt.err)
+		}
+	}
+}